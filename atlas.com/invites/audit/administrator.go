@@ -0,0 +1,31 @@
+package audit
+
+import (
+	"gorm.io/gorm"
+)
+
+func create(db *gorm.DB, ms []Model) error {
+	es := make([]Entity, 0, len(ms))
+	for _, m := range ms {
+		es = append(es, Entity{
+			Id:            m.id,
+			TenantId:      m.tenant.Id(),
+			Region:        m.tenant.Region(),
+			MajorVersion:  m.tenant.MajorVersion(),
+			MinorVersion:  m.tenant.MinorVersion(),
+			At:            m.at,
+			Kind:          string(m.kind),
+			Action:        m.action,
+			InviteType:    m.inviteType,
+			WorldId:       m.worldId,
+			ReferenceId:   m.referenceId,
+			OriginatorId:  m.originatorId,
+			TargetId:      m.targetId,
+			ActorId:       m.actorId,
+			Reason:        m.reason,
+			TransactionId: m.transactionId,
+			TraceId:       m.traceId,
+		})
+	}
+	return db.Create(&es).Error
+}
@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+func Migration(db *gorm.DB) error {
+	return db.AutoMigrate(&Entity{})
+}
+
+// Entity records a single audit log entry. Rows are never updated or deleted by the service.
+type Entity struct {
+	Id            uuid.UUID `gorm:"primaryKey;type:uuid;not null"`
+	TenantId      uuid.UUID `gorm:"type:uuid;not null;index:idx_invite_audit_tenant_at"`
+	Region        string    `gorm:"not null"`
+	MajorVersion  uint16    `gorm:"not null"`
+	MinorVersion  uint16    `gorm:"not null"`
+	At            time.Time `gorm:"not null;index:idx_invite_audit_tenant_at"`
+	Kind          string    `gorm:"not null"`
+	Action        string    `gorm:"not null"`
+	InviteType    string    `gorm:"not null"`
+	WorldId       byte      `gorm:"not null"`
+	ReferenceId   uint32    `gorm:"not null"`
+	OriginatorId  uint32    `gorm:"not null;index"`
+	TargetId      uint32    `gorm:"not null;index"`
+	ActorId       uint32    `gorm:"not null;index"`
+	Reason        string    `gorm:"not null;default:''"`
+	TransactionId uuid.UUID `gorm:"type:uuid;not null"`
+	TraceId       string    `gorm:"not null;default:''"`
+}
+
+func (e Entity) TableName() string {
+	return "invite_audit"
+}
+
+func Make(e Entity) (Model, error) {
+	t, err := tenant.Create(e.TenantId, e.Region, e.MajorVersion, e.MinorVersion)
+	if err != nil {
+		return Model{}, err
+	}
+	return Model{
+		id:            e.Id,
+		tenant:        t,
+		at:            e.At,
+		kind:          Kind(e.Kind),
+		action:        e.Action,
+		inviteType:    e.InviteType,
+		worldId:       e.WorldId,
+		referenceId:   e.ReferenceId,
+		originatorId:  e.OriginatorId,
+		targetId:      e.TargetId,
+		actorId:       e.ActorId,
+		reason:        e.Reason,
+		transactionId: e.TransactionId,
+		traceId:       e.TraceId,
+	}, nil
+}
@@ -0,0 +1,10 @@
+package audit
+
+import "errors"
+
+var (
+	ErrInvalidSink      = errors.New("unknown audit sink")
+	ErrSinkUnavailable  = errors.New("audit sink requires a database")
+	ErrFilePathRequired = errors.New("audit file sink requires AUDIT_FILE_PATH")
+	ErrInvalidFileSize  = errors.New("audit file size must be positive")
+)
@@ -0,0 +1,179 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+const maxLineSize = 64 * 1024
+
+// record is the JSON representation of a Model written to a FileSink, one per line.
+type record struct {
+	Id            uuid.UUID `json:"id"`
+	TenantId      uuid.UUID `json:"tenantId"`
+	Region        string    `json:"region"`
+	MajorVersion  uint16    `json:"majorVersion"`
+	MinorVersion  uint16    `json:"minorVersion"`
+	At            time.Time `json:"at"`
+	Kind          Kind      `json:"kind"`
+	Action        string    `json:"action"`
+	InviteType    string    `json:"inviteType"`
+	WorldId       byte      `json:"worldId"`
+	ReferenceId   uint32    `json:"referenceId"`
+	OriginatorId  uint32    `json:"originatorId"`
+	TargetId      uint32    `json:"targetId"`
+	ActorId       uint32    `json:"actorId"`
+	Reason        string    `json:"reason,omitempty"`
+	TransactionId uuid.UUID `json:"transactionId"`
+	TraceId       string    `json:"traceId,omitempty"`
+}
+
+// FileSink is a Sink appending entries as JSON lines to a file. Once the file would grow beyond maxSize bytes it is
+// rotated to path.1, replacing the previous rotation, so at most two files are kept. Queries scan both files, so it
+// suits development and low volume deployments rather than long retention.
+type FileSink struct {
+	lock    sync.Mutex
+	path    string
+	maxSize int64
+}
+
+func NewFileSink(path string, maxSize int64) *FileSink {
+	return &FileSink{path: path, maxSize: maxSize}
+}
+
+func (s *FileSink) rotated() string {
+	return s.path + ".1"
+}
+
+func (s *FileSink) Append(ms ...Model) error {
+	if len(ms) == 0 {
+		return nil
+	}
+
+	var data []byte
+	for _, m := range ms {
+		line, err := json.Marshal(record{
+			Id:            m.id,
+			TenantId:      m.tenant.Id(),
+			Region:        m.tenant.Region(),
+			MajorVersion:  m.tenant.MajorVersion(),
+			MinorVersion:  m.tenant.MinorVersion(),
+			At:            m.at,
+			Kind:          m.kind,
+			Action:        m.action,
+			InviteType:    m.inviteType,
+			WorldId:       m.worldId,
+			ReferenceId:   m.referenceId,
+			OriginatorId:  m.originatorId,
+			TargetId:      m.targetId,
+			ActorId:       m.actorId,
+			Reason:        m.reason,
+			TransactionId: m.transactionId,
+			TraceId:       m.traceId,
+		})
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	err := s.rotate(int64(len(data)))
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// rotate moves the file aside when appending size bytes would grow it beyond maxSize.
+func (s *FileSink) rotate(size int64) error {
+	fi, err := os.Stat(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Size() == 0 || fi.Size()+size <= s.maxSize {
+		return nil
+	}
+	return os.Rename(s.path, s.rotated())
+}
+
+func (s *FileSink) ForCharacter(t tenant.Model, characterId uint32, fl Filter) ([]Model, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	results := make([]Model, 0)
+	for _, path := range []string{s.rotated(), s.path} {
+		var err error
+		results, err = scan(path, t, characterId, fl, results)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// scan appends the entries in the file at path involving characterId which match fl to results.
+func scan(path string, t tenant.Model, characterId uint32, fl Filter, results []Model) ([]Model, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 4096), maxLineSize)
+	for sc.Scan() {
+		var r record
+		err = json.Unmarshal(sc.Bytes(), &r)
+		if err != nil {
+			return nil, err
+		}
+		if r.TenantId != t.Id() {
+			continue
+		}
+		m := Model{
+			id:            r.Id,
+			tenant:        t,
+			at:            r.At,
+			kind:          r.Kind,
+			action:        r.Action,
+			inviteType:    r.InviteType,
+			worldId:       r.WorldId,
+			referenceId:   r.ReferenceId,
+			originatorId:  r.OriginatorId,
+			targetId:      r.TargetId,
+			actorId:       r.ActorId,
+			reason:        r.Reason,
+			transactionId: r.TransactionId,
+			traceId:       r.TraceId,
+		}
+		if m.Involves(characterId) && fl.Matches(m) {
+			results = append(results, m)
+		}
+	}
+	return results, sc.Err()
+}
@@ -0,0 +1,155 @@
+package audit
+
+import (
+	"errors"
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testTenant(t *testing.T) tenant.Model {
+	tm, err := tenant.Create(uuid.New(), "GMS", 83, 1)
+	if err != nil {
+		t.Fatalf("Unable to create tenant: %v", err)
+	}
+	return tm
+}
+
+func testEntry(tm tenant.Model, at time.Time, inviteType string, originatorId uint32, targetId uint32, actorId uint32) Model {
+	return Model{
+		id:            uuid.New(),
+		tenant:        tm,
+		at:            at,
+		kind:          KindTransition,
+		action:        "PENDING",
+		inviteType:    inviteType,
+		originatorId:  originatorId,
+		targetId:      targetId,
+		actorId:       actorId,
+		transactionId: uuid.New(),
+	}
+}
+
+func ids(ms []Model) []uuid.UUID {
+	results := make([]uuid.UUID, 0, len(ms))
+	for _, m := range ms {
+		results = append(results, m.Id())
+	}
+	return results
+}
+
+func assertIds(t *testing.T, got []Model, want ...Model) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d entries, got %d.", len(want), len(got))
+	}
+	for i, id := range ids(want) {
+		if got[i].Id() != id {
+			t.Errorf("Expected entry %d to be [%s], got [%s].", i, id, got[i].Id())
+		}
+	}
+}
+
+func TestFileSinkWithoutRotation(t *testing.T) {
+	tm := testTenant(t)
+	path := filepath.Join(t.TempDir(), "audit.log")
+	s := NewFileSink(path, 1<<20)
+
+	now := time.Now()
+	a := testEntry(tm, now, "BUDDY", 1, 2, 1)
+	b := testEntry(tm, now.Add(time.Second), "BUDDY", 1, 2, 2)
+	c := testEntry(tm, now.Add(2*time.Second), "PARTY", 3, 1, 3)
+	if err := s.Append(a, b); err != nil {
+		t.Fatalf("Unable to append entries: %v", err)
+	}
+	if err := s.Append(c); err != nil {
+		t.Fatalf("Unable to append entry: %v", err)
+	}
+
+	if _, err := os.Stat(path + ".1"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected no rotated file, got %v.", err)
+	}
+	ms, err := s.ForCharacter(tm, 1, Filter{})
+	if err != nil {
+		t.Fatalf("Unable to scan entries: %v", err)
+	}
+	assertIds(t, ms, a, b, c)
+	if ms[0].Tenant().Id() != tm.Id() || ms[0].InviteType() != "BUDDY" || ms[0].ActorId() != 1 || ms[0].TransactionId() != a.TransactionId() {
+		t.Errorf("Entry was not read back as written.")
+	}
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	tm := testTenant(t)
+	path := filepath.Join(t.TempDir(), "audit.log")
+	// every append beyond the first would grow the file past its limit, so each rotates the file.
+	s := NewFileSink(path, 1)
+
+	now := time.Now()
+	a := testEntry(tm, now, "BUDDY", 1, 2, 1)
+	b := testEntry(tm, now.Add(time.Second), "BUDDY", 1, 2, 2)
+	c := testEntry(tm, now.Add(2*time.Second), "BUDDY", 1, 2, 1)
+	for _, m := range []Model{a, b, c} {
+		if err := s.Append(m); err != nil {
+			t.Fatalf("Unable to append entry: %v", err)
+		}
+	}
+
+	// a is dropped with the previous rotation, and b is read from the rotated file before c.
+	ms, err := s.ForCharacter(tm, 1, Filter{})
+	if err != nil {
+		t.Fatalf("Unable to scan entries: %v", err)
+	}
+	assertIds(t, ms, b, c)
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("Unable to list files: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected 2 files to be kept, got %d.", len(entries))
+	}
+}
+
+func TestFileSinkForCharacterFilters(t *testing.T) {
+	tm := testTenant(t)
+	other := testTenant(t)
+	path := filepath.Join(t.TempDir(), "audit.log")
+	s := NewFileSink(path, 1<<20)
+
+	now := time.Now().Truncate(time.Second)
+	early := testEntry(tm, now.Add(-time.Hour), "BUDDY", 1, 2, 1)
+	party := testEntry(tm, now, "PARTY", 3, 1, 3)
+	acted := testEntry(tm, now.Add(time.Minute), "BUDDY", 4, 5, 1)
+	late := testEntry(tm, now.Add(time.Hour), "BUDDY", 2, 1, 1)
+	unrelated := testEntry(tm, now, "BUDDY", 4, 5, 4)
+	tenanted := testEntry(other, now, "BUDDY", 1, 2, 1)
+	if err := s.Append(early, party, acted, late, unrelated, tenanted); err != nil {
+		t.Fatalf("Unable to append entries: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		f    Filter
+		want []Model
+	}{
+		{"all", Filter{}, []Model{early, party, acted, late}},
+		{"invite type", NewFilter(time.Time{}, time.Time{}, "PARTY"), []Model{party}},
+		{"from inclusive", NewFilter(now, time.Time{}, ""), []Model{party, acted, late}},
+		{"to exclusive", NewFilter(time.Time{}, now.Add(time.Minute), ""), []Model{early, party}},
+		{"range and type", NewFilter(now, now.Add(time.Hour), "BUDDY"), []Model{acted}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms, err := s.ForCharacter(tm, 1, tt.f)
+			if err != nil {
+				t.Fatalf("Unable to scan entries: %v", err)
+			}
+			assertIds(t, ms, tt.want...)
+		})
+	}
+}
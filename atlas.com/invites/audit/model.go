@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Kind string
+
+const (
+	// KindCommand records a command as it was received, before the service acted upon it.
+	KindCommand Kind = "COMMAND"
+	// KindTransition records an invite moving to a new status.
+	KindTransition Kind = "TRANSITION"
+	// KindRefusal records a command the service refused to act upon.
+	KindRefusal Kind = "REFUSAL"
+)
+
+// Model is a single entry of the audit log. Action holds the command type for commands and refusals, and the status the
+// invite moved to for transitions. ActorId is the character which caused the entry, or zero when the service acted on
+// its own, such as when an invite expires.
+type Model struct {
+	id            uuid.UUID
+	tenant        tenant.Model
+	at            time.Time
+	kind          Kind
+	action        string
+	inviteType    string
+	worldId       byte
+	referenceId   uint32
+	originatorId  uint32
+	targetId      uint32
+	actorId       uint32
+	reason        string
+	transactionId uuid.UUID
+	traceId       string
+}
+
+func (m Model) Id() uuid.UUID {
+	return m.id
+}
+
+func (m Model) Tenant() tenant.Model {
+	return m.tenant
+}
+
+func (m Model) At() time.Time {
+	return m.at
+}
+
+func (m Model) Kind() Kind {
+	return m.kind
+}
+
+func (m Model) Action() string {
+	return m.action
+}
+
+func (m Model) InviteType() string {
+	return m.inviteType
+}
+
+func (m Model) WorldId() byte {
+	return m.worldId
+}
+
+func (m Model) ReferenceId() uint32 {
+	return m.referenceId
+}
+
+func (m Model) OriginatorId() uint32 {
+	return m.originatorId
+}
+
+func (m Model) TargetId() uint32 {
+	return m.targetId
+}
+
+func (m Model) ActorId() uint32 {
+	return m.actorId
+}
+
+func (m Model) Reason() string {
+	return m.reason
+}
+
+func (m Model) TransactionId() uuid.UUID {
+	return m.transactionId
+}
+
+func (m Model) TraceId() string {
+	return m.traceId
+}
+
+// Involves reports whether characterId sent, received or acted upon the invite the entry concerns.
+func (m Model) Involves(characterId uint32) bool {
+	return m.originatorId == characterId || m.targetId == characterId || m.actorId == characterId
+}
+
+// Filter narrows the entries returned for a character. A zero from or to leaves that end of the range open, and an
+// empty invite type matches every type.
+type Filter struct {
+	from       time.Time
+	to         time.Time
+	inviteType string
+}
+
+func NewFilter(from time.Time, to time.Time, inviteType string) Filter {
+	return Filter{from: from, to: to, inviteType: inviteType}
+}
+
+func (f Filter) From() time.Time {
+	return f.from
+}
+
+func (f Filter) To() time.Time {
+	return f.to
+}
+
+func (f Filter) InviteType() string {
+	return f.inviteType
+}
+
+// Matches reports whether m falls within the range, which includes from and excludes to, and is of the invite type.
+func (f Filter) Matches(m Model) bool {
+	if !f.from.IsZero() && m.at.Before(f.from) {
+		return false
+	}
+	if !f.to.IsZero() && !m.at.Before(f.to) {
+		return false
+	}
+	return f.inviteType == "" || f.inviteType == m.inviteType
+}
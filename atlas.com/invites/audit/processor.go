@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"context"
+	"github.com/Chronicle20/atlas-model/model"
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/sirupsen/logrus"
+	"github.com/uber/jaeger-client-go"
+	"time"
+)
+
+type Processor interface {
+	GetByCharacterId(characterId uint32, f Filter) ([]Model, error)
+	ByCharacterIdProvider(characterId uint32, f Filter) model.Provider[[]Model]
+	Command(commandType string, worldId byte, inviteType string, referenceId uint32, originatorId uint32, targetId uint32, actorId uint32, transactionId uuid.UUID) Model
+	Transition(status string, worldId byte, inviteType string, referenceId uint32, originatorId uint32, targetId uint32, actorId uint32, reason string, transactionId uuid.UUID) Model
+	Refusal(commandType string, worldId byte, inviteType string, referenceId uint32, originatorId uint32, targetId uint32, actorId uint32, reason string, transactionId uuid.UUID) Model
+	Record(ms ...Model) error
+}
+
+type ProcessorImpl struct {
+	l   logrus.FieldLogger
+	ctx context.Context
+	t   tenant.Model
+	s   Sink
+}
+
+func NewProcessor(l logrus.FieldLogger, ctx context.Context) Processor {
+	return &ProcessorImpl{
+		l:   l,
+		ctx: ctx,
+		t:   tenant.MustFromContext(ctx),
+		s:   GetSink(),
+	}
+}
+
+func (p *ProcessorImpl) GetByCharacterId(characterId uint32, f Filter) ([]Model, error) {
+	return p.ByCharacterIdProvider(characterId, f)()
+}
+
+func (p *ProcessorImpl) ByCharacterIdProvider(characterId uint32, f Filter) model.Provider[[]Model] {
+	ms, err := p.s.ForCharacter(p.t, characterId, f)
+	if err != nil {
+		return model.ErrorProvider[[]Model](err)
+	}
+	return model.FixedProvider(ms)
+}
+
+// Command builds the entry for a command received from actorId.
+func (p *ProcessorImpl) Command(commandType string, worldId byte, inviteType string, referenceId uint32, originatorId uint32, targetId uint32, actorId uint32, transactionId uuid.UUID) Model {
+	return p.make(KindCommand, commandType, worldId, inviteType, referenceId, originatorId, targetId, actorId, "", transactionId)
+}
+
+// Transition builds the entry for an invite moving to status.
+func (p *ProcessorImpl) Transition(status string, worldId byte, inviteType string, referenceId uint32, originatorId uint32, targetId uint32, actorId uint32, reason string, transactionId uuid.UUID) Model {
+	return p.make(KindTransition, status, worldId, inviteType, referenceId, originatorId, targetId, actorId, reason, transactionId)
+}
+
+// Refusal builds the entry for a command the service refused for reason.
+func (p *ProcessorImpl) Refusal(commandType string, worldId byte, inviteType string, referenceId uint32, originatorId uint32, targetId uint32, actorId uint32, reason string, transactionId uuid.UUID) Model {
+	return p.make(KindRefusal, commandType, worldId, inviteType, referenceId, originatorId, targetId, actorId, reason, transactionId)
+}
+
+func (p *ProcessorImpl) make(kind Kind, action string, worldId byte, inviteType string, referenceId uint32, originatorId uint32, targetId uint32, actorId uint32, reason string, transactionId uuid.UUID) Model {
+	var traceId string
+	if span := opentracing.SpanFromContext(p.ctx); span != nil {
+		if sc, ok := span.Context().(jaeger.SpanContext); ok && sc.TraceID().IsValid() {
+			traceId = sc.TraceID().String()
+		}
+	}
+	return Model{
+		id:            uuid.New(),
+		tenant:        p.t,
+		at:            time.Now(),
+		kind:          kind,
+		action:        action,
+		inviteType:    inviteType,
+		worldId:       worldId,
+		referenceId:   referenceId,
+		originatorId:  originatorId,
+		targetId:      targetId,
+		actorId:       actorId,
+		reason:        reason,
+		transactionId: transactionId,
+		traceId:       traceId,
+	}
+}
+
+// Record appends ms to the audit log. Failures are logged, as the work being audited has already been performed.
+func (p *ProcessorImpl) Record(ms ...Model) error {
+	err := p.s.Append(ms...)
+	if err != nil {
+		p.l.WithError(err).Errorf("Unable to record [%d] audit log entries.", len(ms))
+	}
+	return err
+}
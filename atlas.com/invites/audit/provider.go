@@ -0,0 +1,34 @@
+package audit
+
+import (
+	"atlas-invites/database"
+	"github.com/Chronicle20/atlas-model/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func getForCharacter(tenantId uuid.UUID) func(characterId uint32) func(f Filter) database.EntityProvider[[]Entity] {
+	return func(characterId uint32) func(f Filter) database.EntityProvider[[]Entity] {
+		return func(f Filter) database.EntityProvider[[]Entity] {
+			return func(db *gorm.DB) model.Provider[[]Entity] {
+				q := db.Where("tenant_id = ? AND (originator_id = ? OR target_id = ? OR actor_id = ?)", tenantId, characterId, characterId, characterId)
+				if !f.From().IsZero() {
+					q = q.Where("at >= ?", f.From())
+				}
+				if !f.To().IsZero() {
+					q = q.Where("at < ?", f.To())
+				}
+				if f.InviteType() != "" {
+					q = q.Where("invite_type = ?", f.InviteType())
+				}
+
+				var results []Entity
+				err := q.Order("at").Find(&results).Error
+				if err != nil {
+					return model.ErrorProvider[[]Entity](err)
+				}
+				return model.FixedProvider(results)
+			}
+		}
+	}
+}
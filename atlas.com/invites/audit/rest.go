@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"time"
+)
+
+type RestModel struct {
+	Id            string    `json:"-"`
+	At            time.Time `json:"at"`
+	Kind          string    `json:"kind"`
+	Action        string    `json:"action"`
+	Type          string    `json:"type"`
+	WorldId       byte      `json:"worldId"`
+	ReferenceId   uint32    `json:"referenceId"`
+	OriginatorId  uint32    `json:"originatorId"`
+	TargetId      uint32    `json:"targetId"`
+	ActorId       uint32    `json:"actorId"`
+	Reason        string    `json:"reason,omitempty"`
+	TransactionId string    `json:"transactionId"`
+	TraceId       string    `json:"traceId,omitempty"`
+}
+
+func (r RestModel) GetName() string {
+	return "invite-history"
+}
+
+func (r RestModel) GetID() string {
+	return r.Id
+}
+
+func (r *RestModel) SetID(strId string) error {
+	r.Id = strId
+	return nil
+}
+
+func Transform(m Model) (RestModel, error) {
+	return RestModel{
+		Id:            m.id.String(),
+		At:            m.at,
+		Kind:          string(m.kind),
+		Action:        m.action,
+		Type:          m.inviteType,
+		WorldId:       m.worldId,
+		ReferenceId:   m.referenceId,
+		OriginatorId:  m.originatorId,
+		TargetId:      m.targetId,
+		ActorId:       m.actorId,
+		Reason:        m.reason,
+		TransactionId: m.transactionId.String(),
+		TraceId:       m.traceId,
+	}, nil
+}
@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"gorm.io/gorm"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	SinkTypeFile = "FILE"
+	SinkTypeSql  = "SQL"
+	SinkTypeNone = "NONE"
+
+	defaultFileMaxSize = 64 << 20
+)
+
+// Sink stores the audit log. Entries are only ever appended. ForCharacter returns the entries involving characterId
+// which match f, oldest first. Implementations must be safe for concurrent use.
+type Sink interface {
+	Append(ms ...Model) error
+	ForCharacter(t tenant.Model, characterId uint32, f Filter) ([]Model, error)
+}
+
+var sink Sink
+var once sync.Once
+
+// InitSink sets the Sink used by the service, and panics if one is already set.
+func InitSink(s Sink) {
+	set := false
+	once.Do(func() {
+		sink = s
+		set = true
+	})
+	if !set {
+		panic("audit sink already set")
+	}
+}
+
+// GetSink returns the configured Sink, defaulting to one which discards every entry.
+func GetSink() Sink {
+	once.Do(func() {
+		sink = NoopSink{}
+	})
+	return sink
+}
+
+// NoopSink discards every entry, disabling the audit log.
+type NoopSink struct {
+}
+
+func (s NoopSink) Append(_ ...Model) error {
+	return nil
+}
+
+func (s NoopSink) ForCharacter(_ tenant.Model, _ uint32, _ Filter) ([]Model, error) {
+	return make([]Model, 0), nil
+}
+
+// SinkFromEnv selects the Sink named by AUDIT_SINK, which is one of FILE, SQL or NONE. When it is unset the audit log
+// is kept in the database if one is configured, and is otherwise disabled. The file sink must be selected explicitly
+// and writes to AUDIT_FILE_PATH, rotating it once it would exceed AUDIT_FILE_MAX_SIZE bytes, defaulting to 64 MiB.
+func SinkFromEnv(db *gorm.DB) (Sink, error) {
+	st, ok := os.LookupEnv("AUDIT_SINK")
+	if !ok || st == "" {
+		st = SinkTypeNone
+		if db != nil {
+			st = SinkTypeSql
+		}
+	}
+
+	switch strings.ToUpper(st) {
+	case SinkTypeFile:
+		path, ok := os.LookupEnv("AUDIT_FILE_PATH")
+		if !ok || path == "" {
+			return nil, ErrFilePathRequired
+		}
+		maxSize, err := fileMaxSizeFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return NewFileSink(path, maxSize), nil
+	case SinkTypeSql:
+		if db == nil {
+			return nil, ErrSinkUnavailable
+		}
+		return NewDatabaseSink(db), nil
+	case SinkTypeNone:
+		return NoopSink{}, nil
+	}
+	return nil, ErrInvalidSink
+}
+
+func fileMaxSizeFromEnv() (int64, error) {
+	val, ok := os.LookupEnv("AUDIT_FILE_MAX_SIZE")
+	if !ok || val == "" {
+		return defaultFileMaxSize, nil
+	}
+	size, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if size <= 0 {
+		return 0, ErrInvalidFileSize
+	}
+	return size, nil
+}
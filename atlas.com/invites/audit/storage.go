@@ -0,0 +1,27 @@
+package audit
+
+import (
+	"github.com/Chronicle20/atlas-model/model"
+	"github.com/Chronicle20/atlas-tenant"
+	"gorm.io/gorm"
+)
+
+// DatabaseSink is a Sink backed by the invite_audit table.
+type DatabaseSink struct {
+	db *gorm.DB
+}
+
+func NewDatabaseSink(db *gorm.DB) *DatabaseSink {
+	return &DatabaseSink{db: db}
+}
+
+func (s *DatabaseSink) Append(ms ...Model) error {
+	if len(ms) == 0 {
+		return nil
+	}
+	return create(s.db, ms)
+}
+
+func (s *DatabaseSink) ForCharacter(t tenant.Model, characterId uint32, f Filter) ([]Model, error) {
+	return model.SliceMap(Make)(getForCharacter(t.Id())(characterId)(f)(s.db))()()
+}
@@ -0,0 +1,29 @@
+package block
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"gorm.io/gorm"
+	"time"
+)
+
+func create(db *gorm.DB, t tenant.Model, characterId uint32, blockedId uint32, createdAt time.Time) (Entity, error) {
+	e := Entity{
+		TenantId:     t.Id(),
+		CharacterId:  characterId,
+		BlockedId:    blockedId,
+		Region:       t.Region(),
+		MajorVersion: t.MajorVersion(),
+		MinorVersion: t.MinorVersion(),
+		CreatedAt:    createdAt,
+	}
+	err := db.Create(&e).Error
+	if err != nil {
+		return Entity{}, err
+	}
+	return e, nil
+}
+
+func deleteByBlockedId(db *gorm.DB, t tenant.Model, characterId uint32, blockedId uint32) (int64, error) {
+	res := db.Where("tenant_id = ? AND character_id = ? AND blocked_id = ?", t.Id(), characterId, blockedId).Delete(&Entity{})
+	return res.RowsAffected, res.Error
+}
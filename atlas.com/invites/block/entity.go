@@ -0,0 +1,39 @@
+package block
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+func Migration(db *gorm.DB) error {
+	return db.AutoMigrate(&Entity{})
+}
+
+type Entity struct {
+	TenantId     uuid.UUID `gorm:"primaryKey;type:uuid;not null"`
+	CharacterId  uint32    `gorm:"primaryKey;autoIncrement:false;not null"`
+	BlockedId    uint32    `gorm:"primaryKey;autoIncrement:false;not null"`
+	Region       string    `gorm:"not null"`
+	MajorVersion uint16    `gorm:"not null"`
+	MinorVersion uint16    `gorm:"not null"`
+	CreatedAt    time.Time `gorm:"not null"`
+}
+
+func (e Entity) TableName() string {
+	return "blocks"
+}
+
+func Make(e Entity) (Model, error) {
+	t, err := tenant.Create(e.TenantId, e.Region, e.MajorVersion, e.MinorVersion)
+	if err != nil {
+		return Model{}, err
+	}
+	return Model{
+		tenant:      t,
+		characterId: e.CharacterId,
+		blockedId:   e.BlockedId,
+		createdAt:   e.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,8 @@
+package block
+
+import "errors"
+
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrSelfBlock = errors.New("cannot block self")
+)
@@ -0,0 +1,30 @@
+package block
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"time"
+)
+
+// Model records that a character refuses invites from another character.
+type Model struct {
+	tenant      tenant.Model
+	characterId uint32
+	blockedId   uint32
+	createdAt   time.Time
+}
+
+func (m Model) Tenant() tenant.Model {
+	return m.tenant
+}
+
+func (m Model) CharacterId() uint32 {
+	return m.characterId
+}
+
+func (m Model) BlockedId() uint32 {
+	return m.blockedId
+}
+
+func (m Model) CreatedAt() time.Time {
+	return m.createdAt
+}
@@ -0,0 +1,86 @@
+package block
+
+import (
+	"context"
+	"errors"
+	"github.com/Chronicle20/atlas-model/model"
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/sirupsen/logrus"
+)
+
+type Processor interface {
+	GetByCharacterId(characterId uint32) ([]Model, error)
+	ByCharacterIdProvider(characterId uint32) model.Provider[[]Model]
+	Get(characterId uint32, blockedId uint32) (Model, error)
+	Add(characterId uint32, blockedId uint32) (Model, error)
+	Remove(characterId uint32, blockedId uint32) error
+	IsBlocked(characterId uint32, blockedId uint32) (bool, error)
+}
+
+type ProcessorImpl struct {
+	l   logrus.FieldLogger
+	ctx context.Context
+	t   tenant.Model
+	r   Registry
+}
+
+func NewProcessor(l logrus.FieldLogger, ctx context.Context) Processor {
+	return &ProcessorImpl{
+		l:   l,
+		ctx: ctx,
+		t:   tenant.MustFromContext(ctx),
+		r:   GetRegistry(),
+	}
+}
+
+func (p *ProcessorImpl) GetByCharacterId(characterId uint32) ([]Model, error) {
+	return p.ByCharacterIdProvider(characterId)()
+}
+
+func (p *ProcessorImpl) ByCharacterIdProvider(characterId uint32) model.Provider[[]Model] {
+	bs, err := p.r.GetForCharacter(p.t, characterId)
+	if err != nil {
+		return model.ErrorProvider[[]Model](err)
+	}
+	return model.FixedProvider(bs)
+}
+
+func (p *ProcessorImpl) Get(characterId uint32, blockedId uint32) (Model, error) {
+	return p.r.Get(p.t, characterId, blockedId)
+}
+
+// Add blocks blockedId from inviting characterId.
+func (p *ProcessorImpl) Add(characterId uint32, blockedId uint32) (Model, error) {
+	if characterId == blockedId {
+		return Model{}, ErrSelfBlock
+	}
+	m, err := p.r.Add(p.t, characterId, blockedId)
+	if err != nil {
+		p.l.WithError(err).Errorf("Unable to block character [%d] for character [%d].", blockedId, characterId)
+		return Model{}, err
+	}
+	p.l.Infof("Character [%d] blocked invites from character [%d].", characterId, blockedId)
+	return m, nil
+}
+
+// Remove allows blockedId to invite characterId again.
+func (p *ProcessorImpl) Remove(characterId uint32, blockedId uint32) error {
+	err := p.r.Remove(p.t, characterId, blockedId)
+	if err != nil {
+		return err
+	}
+	p.l.Infof("Character [%d] unblocked invites from character [%d].", characterId, blockedId)
+	return nil
+}
+
+// IsBlocked reports whether characterId refuses invites from blockedId.
+func (p *ProcessorImpl) IsBlocked(characterId uint32, blockedId uint32) (bool, error) {
+	_, err := p.r.Get(p.t, characterId, blockedId)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,26 @@
+package block
+
+import (
+	"atlas-invites/database"
+	"github.com/Chronicle20/atlas-model/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func getByBlockedId(tenantId uuid.UUID) func(characterId uint32) func(blockedId uint32) database.EntityProvider[Entity] {
+	return func(characterId uint32) func(blockedId uint32) database.EntityProvider[Entity] {
+		return func(blockedId uint32) database.EntityProvider[Entity] {
+			return func(db *gorm.DB) model.Provider[Entity] {
+				return database.Query[Entity](db, map[string]interface{}{"tenant_id": tenantId, "character_id": characterId, "blocked_id": blockedId})
+			}
+		}
+	}
+}
+
+func getForCharacter(tenantId uuid.UUID) func(characterId uint32) database.EntityProvider[[]Entity] {
+	return func(characterId uint32) database.EntityProvider[[]Entity] {
+		return func(db *gorm.DB) model.Provider[[]Entity] {
+			return database.SliceQuery[Entity](db, map[string]interface{}{"tenant_id": tenantId, "character_id": characterId})
+		}
+	}
+}
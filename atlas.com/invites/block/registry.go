@@ -0,0 +1,110 @@
+package block
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Registry tracks the characters each character has blocked. Implementations must be safe for concurrent use. Add is
+// idempotent and returns the existing block when the character has already blocked blockedId.
+type Registry interface {
+	Add(t tenant.Model, characterId uint32, blockedId uint32) (Model, error)
+	Get(t tenant.Model, characterId uint32, blockedId uint32) (Model, error)
+	GetForCharacter(t tenant.Model, characterId uint32) ([]Model, error)
+	Remove(t tenant.Model, characterId uint32, blockedId uint32) error
+}
+
+var registry Registry
+var once sync.Once
+
+// InitRegistry sets the Registry used by the service, and panics if one is already set.
+func InitRegistry(r Registry) {
+	set := false
+	once.Do(func() {
+		registry = r
+		set = true
+	})
+	if !set {
+		panic("block registry already set")
+	}
+}
+
+// GetRegistry returns the configured Registry, defaulting to an in-memory implementation.
+func GetRegistry() Registry {
+	once.Do(func() {
+		registry = NewInMemoryRegistry()
+	})
+	return registry
+}
+
+type InMemoryRegistry struct {
+	lock   sync.RWMutex
+	blocks map[tenant.Model]map[uint32]map[uint32]Model
+}
+
+func NewInMemoryRegistry() *InMemoryRegistry {
+	return &InMemoryRegistry{blocks: make(map[tenant.Model]map[uint32]map[uint32]Model)}
+}
+
+func (r *InMemoryRegistry) Add(t tenant.Model, characterId uint32, blockedId uint32) (Model, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.blocks[t]; !ok {
+		r.blocks[t] = make(map[uint32]map[uint32]Model)
+	}
+	if _, ok := r.blocks[t][characterId]; !ok {
+		r.blocks[t][characterId] = make(map[uint32]Model)
+	}
+	if m, ok := r.blocks[t][characterId][blockedId]; ok {
+		return m, nil
+	}
+	m := Model{
+		tenant:      t,
+		characterId: characterId,
+		blockedId:   blockedId,
+		createdAt:   time.Now(),
+	}
+	r.blocks[t][characterId][blockedId] = m
+	return m, nil
+}
+
+func (r *InMemoryRegistry) Get(t tenant.Model, characterId uint32, blockedId uint32) (Model, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if m, ok := r.blocks[t][characterId][blockedId]; ok {
+		return m, nil
+	}
+	return Model{}, ErrNotFound
+}
+
+func (r *InMemoryRegistry) GetForCharacter(t tenant.Model, characterId uint32) ([]Model, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	results := make([]Model, 0, len(r.blocks[t][characterId]))
+	for _, m := range r.blocks[t][characterId] {
+		results = append(results, m)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].blockedId < results[j].blockedId
+	})
+	return results, nil
+}
+
+func (r *InMemoryRegistry) Remove(t tenant.Model, characterId uint32, blockedId uint32) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.blocks[t][characterId][blockedId]; !ok {
+		return ErrNotFound
+	}
+	delete(r.blocks[t][characterId], blockedId)
+	if len(r.blocks[t][characterId]) == 0 {
+		delete(r.blocks[t], characterId)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package block
+
+import (
+	"atlas-invites/rest"
+	"errors"
+	"github.com/Chronicle20/atlas-model/model"
+	"github.com/Chronicle20/atlas-rest/server"
+	"github.com/gorilla/mux"
+	"github.com/jtumidanski/api2go/jsonapi"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+const (
+	GetBlocks   = "get_character_blocks"
+	GetBlock    = "get_character_block"
+	CreateBlock = "create_character_block"
+	DeleteBlock = "delete_character_block"
+)
+
+func InitResource(si jsonapi.ServerInformation) server.RouteInitializer {
+	return func(router *mux.Router, l logrus.FieldLogger) {
+		registerHandler := rest.RegisterHandler(l)(si)
+		registerInputHandler := rest.RegisterInputHandler[RestModel](l)(si)
+		r := router.PathPrefix("/characters/{characterId}/blocks").Subrouter()
+		r.HandleFunc("", registerHandler(GetBlocks, handleGetBlocks)).Methods(http.MethodGet)
+		r.HandleFunc("", registerInputHandler(CreateBlock, handleCreateBlock)).Methods(http.MethodPost)
+		r.HandleFunc("/{blockedId}", registerHandler(GetBlock, handleGetBlock)).Methods(http.MethodGet)
+		r.HandleFunc("/{blockedId}", registerHandler(DeleteBlock, handleDeleteBlock)).Methods(http.MethodDelete)
+	}
+}
+
+func handleGetBlocks(d *rest.HandlerDependency, c *rest.HandlerContext) http.HandlerFunc {
+	return rest.ParseCharacterId(d.Logger(), func(characterId uint32) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			res, err := model.SliceMap(Transform)(NewProcessor(d.Logger(), d.Context()).ByCharacterIdProvider(characterId))()()
+			if err != nil {
+				d.Logger().WithError(err).Errorf("Creating REST model.")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			query := r.URL.Query()
+			queryParams := jsonapi.ParseQueryFields(&query)
+			server.MarshalResponse[[]RestModel](d.Logger())(w)(c.ServerInformation())(queryParams)(res)
+		}
+	})
+}
+
+func handleGetBlock(d *rest.HandlerDependency, c *rest.HandlerContext) http.HandlerFunc {
+	return rest.ParseCharacterId(d.Logger(), func(characterId uint32) http.HandlerFunc {
+		return rest.ParseBlockedId(d.Logger(), func(blockedId uint32) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				b, err := NewProcessor(d.Logger(), d.Context()).Get(characterId, blockedId)
+				if err != nil {
+					w.WriteHeader(statusForError(err))
+					return
+				}
+
+				res, err := model.Map(Transform)(model.FixedProvider(b))()
+				if err != nil {
+					d.Logger().WithError(err).Errorf("Creating REST model.")
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+
+				query := r.URL.Query()
+				queryParams := jsonapi.ParseQueryFields(&query)
+				server.MarshalResponse[RestModel](d.Logger())(w)(c.ServerInformation())(queryParams)(res)
+			}
+		})
+	})
+}
+
+func handleCreateBlock(d *rest.HandlerDependency, c *rest.HandlerContext, input RestModel) http.HandlerFunc {
+	return rest.ParseCharacterId(d.Logger(), func(characterId uint32) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			blockedId := input.BlockedId
+			if blockedId == 0 {
+				blockedId = input.Id
+			}
+			if blockedId == 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			b, err := NewProcessor(d.Logger(), d.Context()).Add(characterId, blockedId)
+			if err != nil {
+				w.WriteHeader(statusForError(err))
+				return
+			}
+
+			res, err := model.Map(Transform)(model.FixedProvider(b))()
+			if err != nil {
+				d.Logger().WithError(err).Errorf("Creating REST model.")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			query := r.URL.Query()
+			queryParams := jsonapi.ParseQueryFields(&query)
+			server.MarshalResponse[RestModel](d.Logger())(w)(c.ServerInformation())(queryParams)(res)
+		}
+	})
+}
+
+func handleDeleteBlock(d *rest.HandlerDependency, _ *rest.HandlerContext) http.HandlerFunc {
+	return rest.ParseCharacterId(d.Logger(), func(characterId uint32) http.HandlerFunc {
+		return rest.ParseBlockedId(d.Logger(), func(blockedId uint32) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				err := NewProcessor(d.Logger(), d.Context()).Remove(characterId, blockedId)
+				if err != nil {
+					w.WriteHeader(statusForError(err))
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}
+		})
+	})
+}
+
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrSelfBlock):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,39 @@
+package block
+
+import (
+	"strconv"
+	"time"
+)
+
+type RestModel struct {
+	Id          uint32    `json:"-"`
+	CharacterId uint32    `json:"characterId"`
+	BlockedId   uint32    `json:"blockedId"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func (r RestModel) GetName() string {
+	return "blocks"
+}
+
+func (r RestModel) GetID() string {
+	return strconv.Itoa(int(r.Id))
+}
+
+func (r *RestModel) SetID(strId string) error {
+	id, err := strconv.Atoi(strId)
+	if err != nil {
+		return err
+	}
+	r.Id = uint32(id)
+	return nil
+}
+
+func Transform(m Model) (RestModel, error) {
+	return RestModel{
+		Id:          m.blockedId,
+		CharacterId: m.characterId,
+		BlockedId:   m.blockedId,
+		CreatedAt:   m.createdAt,
+	}, nil
+}
@@ -0,0 +1,56 @@
+package block
+
+import (
+	"errors"
+	"github.com/Chronicle20/atlas-model/model"
+	"github.com/Chronicle20/atlas-tenant"
+	"gorm.io/gorm"
+	"time"
+)
+
+// DatabaseRegistry is a Registry backed by a relational database so block lists survive restarts.
+type DatabaseRegistry struct {
+	db *gorm.DB
+}
+
+func NewDatabaseRegistry(db *gorm.DB) *DatabaseRegistry {
+	return &DatabaseRegistry{db: db}
+}
+
+func (r *DatabaseRegistry) Add(t tenant.Model, characterId uint32, blockedId uint32) (Model, error) {
+	m, err := r.Get(t, characterId, blockedId)
+	if err == nil {
+		return m, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return Model{}, err
+	}
+	e, err := create(r.db, t, characterId, blockedId, time.Now())
+	if err != nil {
+		return Model{}, err
+	}
+	return Make(e)
+}
+
+func (r *DatabaseRegistry) Get(t tenant.Model, characterId uint32, blockedId uint32) (Model, error) {
+	m, err := model.Map(Make)(getByBlockedId(t.Id())(characterId)(blockedId)(r.db))()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Model{}, ErrNotFound
+	}
+	return m, err
+}
+
+func (r *DatabaseRegistry) GetForCharacter(t tenant.Model, characterId uint32) ([]Model, error) {
+	return model.SliceMap(Make)(getForCharacter(t.Id())(characterId)(r.db))()()
+}
+
+func (r *DatabaseRegistry) Remove(t tenant.Model, characterId uint32, blockedId uint32) error {
+	n, err := deleteByBlockedId(r.db, t, characterId, blockedId)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
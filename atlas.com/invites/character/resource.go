@@ -1,25 +1,39 @@
 package character
 
 import (
+	"atlas-invites/audit"
 	"atlas-invites/invite"
 	"atlas-invites/rest"
+	"fmt"
 	"github.com/Chronicle20/atlas-model/model"
 	"github.com/Chronicle20/atlas-rest/server"
 	"github.com/gorilla/mux"
 	"github.com/jtumidanski/api2go/jsonapi"
 	"github.com/sirupsen/logrus"
 	"net/http"
+	"time"
 )
 
 const (
-	GetCharacterInvites = "get_character_invites"
+	GetCharacterInvites     = "get_character_invites"
+	GetCharacterSentInvites = "get_character_sent_invites"
+	GetCharacterCooldowns   = "get_character_invite_cooldowns"
+	GetCharacterHistory     = "get_character_invite_history"
+	StreamCharacterInvites  = "stream_character_invites"
+
+	keepAliveInterval = 15 * time.Second
 )
 
 func InitResource(si jsonapi.ServerInformation) server.RouteInitializer {
 	return func(router *mux.Router, l logrus.FieldLogger) {
 		registerGet := rest.RegisterHandler(l)(si)
+		registerStream := rest.RegisterStreamHandler(l)(si)
 		r := router.PathPrefix("/characters").Subrouter()
 		r.HandleFunc("/{characterId}/invites", registerGet(GetCharacterInvites, handleGetCharacterInvites)).Methods(http.MethodGet)
+		r.HandleFunc("/{characterId}/invites/stream", registerStream(StreamCharacterInvites, handleStreamCharacterInvites)).Methods(http.MethodGet)
+		r.HandleFunc("/{characterId}/invites/sent", registerGet(GetCharacterSentInvites, handleGetCharacterSentInvites)).Methods(http.MethodGet)
+		r.HandleFunc("/{characterId}/invite-cooldowns/{targetId}", registerGet(GetCharacterCooldowns, handleGetCharacterCooldowns)).Methods(http.MethodGet)
+		r.HandleFunc("/{characterId}/invite-history", registerGet(GetCharacterHistory, handleGetCharacterHistory)).Methods(http.MethodGet)
 	}
 }
 
@@ -46,3 +60,134 @@ func handleGetCharacterInvites(d *rest.HandlerDependency, c *rest.HandlerContext
 		}
 	})
 }
+
+// handleStreamCharacterInvites pushes updates to the invites a character receives as Server-Sent Events. Each event is
+// named for the status event type and carries the invite as a JSON:API document. The stream ends when the client
+// disconnects, or when the client falls too far behind, in which case it is expected to reconnect and re-read its
+// invites.
+func handleStreamCharacterInvites(d *rest.HandlerDependency, c *rest.HandlerContext) http.HandlerFunc {
+	return rest.ParseCharacterId(d.Logger(), func(characterId uint32) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			f, ok := w.(http.Flusher)
+			if !ok {
+				d.Logger().Errorf("Response writer does not support streaming.")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			us, unsubscribe := invite.NewProcessor(d.Logger(), d.Context()).Subscribe(characterId)
+			defer unsubscribe()
+
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("Connection", "keep-alive")
+			w.WriteHeader(http.StatusOK)
+			f.Flush()
+
+			ticker := time.NewTicker(keepAliveInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-r.Context().Done():
+					return
+				case <-ticker.C:
+					_, err := fmt.Fprint(w, ": keep-alive\n\n")
+					if err != nil {
+						return
+					}
+				case u, ok := <-us:
+					if !ok {
+						d.Logger().Debugf("Ending invite stream for character [%d] which fell behind.", characterId)
+						return
+					}
+					rm, err := invite.Transform(u.Invite())
+					if err != nil {
+						d.Logger().WithError(err).Errorf("Creating REST model.")
+						return
+					}
+					data, err := jsonapi.MarshalWithURLs(rm, c.ServerInformation())
+					if err != nil {
+						d.Logger().WithError(err).Errorf("Marshalling invite [%d].", u.Invite().Id())
+						return
+					}
+					_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", u.EventType(), data)
+					if err != nil {
+						return
+					}
+				}
+				f.Flush()
+			}
+		}
+	})
+}
+
+func handleGetCharacterSentInvites(d *rest.HandlerDependency, c *rest.HandlerContext) http.HandlerFunc {
+	return rest.ParseCharacterId(d.Logger(), func(characterId uint32) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			res, err := model.SliceMap(invite.Transform)(invite.NewProcessor(d.Logger(), d.Context()).ByOriginatorIdProvider(characterId))()()
+			if err != nil {
+				d.Logger().WithError(err).Errorf("Creating REST model.")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			query := r.URL.Query()
+			queryParams := jsonapi.ParseQueryFields(&query)
+			server.MarshalResponse[[]invite.RestModel](d.Logger())(w)(c.ServerInformation())(queryParams)(res)
+		}
+	})
+}
+
+func handleGetCharacterCooldowns(d *rest.HandlerDependency, c *rest.HandlerContext) http.HandlerFunc {
+	return rest.ParseCharacterId(d.Logger(), func(characterId uint32) http.HandlerFunc {
+		return rest.ParseTargetId(d.Logger(), func(targetId uint32) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				res, err := model.SliceMap(invite.TransformCooldown)(invite.NewProcessor(d.Logger(), d.Context()).CooldownsProvider(characterId, targetId))()()
+				if err != nil {
+					d.Logger().WithError(err).Errorf("Creating REST model.")
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+
+				query := r.URL.Query()
+				queryParams := jsonapi.ParseQueryFields(&query)
+				server.MarshalResponse[[]invite.CooldownRestModel](d.Logger())(w)(c.ServerInformation())(queryParams)(res)
+			}
+		})
+	})
+}
+
+// handleGetCharacterHistory returns the audit log entries involving a character. The optional from and to query
+// parameters are RFC 3339 timestamps bounding the range, and type restricts the entries to a single invite type.
+func handleGetCharacterHistory(d *rest.HandlerDependency, c *rest.HandlerContext) http.HandlerFunc {
+	return rest.ParseCharacterId(d.Logger(), func(characterId uint32) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			var bounds [2]time.Time
+			for i, name := range []string{"from", "to"} {
+				val := query.Get(name)
+				if val == "" {
+					continue
+				}
+				var err error
+				bounds[i], err = time.Parse(time.RFC3339, val)
+				if err != nil {
+					d.Logger().WithError(err).Errorf("Unable to properly parse [%s] from query.", name)
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+			}
+			f := audit.NewFilter(bounds[0], bounds[1], query.Get("type"))
+
+			res, err := model.SliceMap(audit.Transform)(audit.NewProcessor(d.Logger(), d.Context()).ByCharacterIdProvider(characterId, f))()()
+			if err != nil {
+				d.Logger().WithError(err).Errorf("Creating REST model.")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			queryParams := jsonapi.ParseQueryFields(&query)
+			server.MarshalResponse[[]audit.RestModel](d.Logger())(w)(c.ServerInformation())(queryParams)(res)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"os"
+	"time"
+)
+
+type Migrator func(db *gorm.DB) error
+
+type Config struct {
+	dsn        string
+	attempts   int
+	delay      time.Duration
+	migrations []Migrator
+}
+
+type Configurator func(c *Config)
+
+func SetMigrations(migrations ...Migrator) Configurator {
+	return func(c *Config) {
+		c.migrations = append(c.migrations, migrations...)
+	}
+}
+
+func Connect(l logrus.FieldLogger, configurators ...Configurator) *gorm.DB {
+	c := &Config{
+		dsn:        fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable", os.Getenv("DB_HOST"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"), os.Getenv("DB_PORT")),
+		attempts:   10,
+		delay:      time.Second,
+		migrations: make([]Migrator, 0),
+	}
+	for _, configurator := range configurators {
+		configurator(c)
+	}
+
+	var db *gorm.DB
+	var err error
+	for attempt := 1; attempt <= c.attempts; attempt++ {
+		db, err = gorm.Open(postgres.New(postgres.Config{DSN: c.dsn, PreferSimpleProtocol: true}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		if err == nil {
+			break
+		}
+		l.WithError(err).Warnf("Failed to connect to database on attempt [%d].", attempt)
+		time.Sleep(c.delay)
+	}
+	if err != nil {
+		l.WithError(err).Fatalf("Failed to connect to database.")
+	}
+
+	for _, m := range c.migrations {
+		err = m(db)
+		if err != nil {
+			l.WithError(err).Fatalf("Failed to run database migration.")
+		}
+	}
+	return db
+}
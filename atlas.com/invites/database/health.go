@@ -0,0 +1,17 @@
+package database
+
+import (
+	"context"
+	"gorm.io/gorm"
+)
+
+// PingCheck reports whether the database accepts connections.
+func PingCheck(db *gorm.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
@@ -0,0 +1,11 @@
+package database
+
+import "gorm.io/gorm"
+
+// ExecuteTransaction runs f inside a transaction, reusing the caller's transaction when one is already open.
+func ExecuteTransaction(db *gorm.DB, f func(tx *gorm.DB) error) error {
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		return f(db)
+	}
+	return db.Transaction(f)
+}
@@ -0,0 +1,74 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Check reports why a dependency of the service is unhealthy, or nil when it is healthy.
+type Check func(ctx context.Context) error
+
+var ErrShuttingDown = errors.New("shutting down")
+
+// Checker evaluates the checks which must pass for the service to be ready to accept work. Once ctx is done the
+// service is reported unready regardless of the checks.
+type Checker struct {
+	ctx     context.Context
+	timeout time.Duration
+	names   []string
+	checks  map[string]Check
+}
+
+func NewChecker(ctx context.Context, timeout time.Duration) *Checker {
+	return &Checker{ctx: ctx, timeout: timeout, checks: make(map[string]Check)}
+}
+
+func (c *Checker) AddCheck(name string, check Check) *Checker {
+	c.names = append(c.names, name)
+	c.checks[name] = check
+	return c
+}
+
+// Ready runs every check concurrently and returns the error produced by each, keyed by check name. The service is
+// ready when every error is nil.
+func (c *Checker) Ready(ctx context.Context) map[string]error {
+	results := make(map[string]error)
+	if c.ctx.Err() != nil {
+		results["teardown"] = ErrShuttingDown
+		return results
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range c.names {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			err := check(ctx)
+			lock.Lock()
+			results[name] = err
+			lock.Unlock()
+		}(name, c.checks[name])
+	}
+	wg.Wait()
+	return results
+}
+
+// Ticked reports a task as unhealthy when it has not completed a run, as reported by last, within the given window.
+func Ticked(last func() time.Time, within time.Duration) Check {
+	return func(ctx context.Context) error {
+		t := last()
+		if t.IsZero() {
+			return errors.New("task has not run yet")
+		}
+		if time.Since(t) > within {
+			return errors.New("task last ran at " + t.Format(time.RFC3339))
+		}
+		return nil
+	}
+}
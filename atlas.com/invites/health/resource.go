@@ -0,0 +1,61 @@
+package health
+
+import (
+	"encoding/json"
+	"github.com/Chronicle20/atlas-rest/server"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+const (
+	StatusOk          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+type RestModel struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// InitResource registers /healthz, which succeeds whenever the service is able to serve requests, and /readyz, which
+// succeeds only while every check registered with c passes. Neither requires tenant headers.
+func InitResource(c *Checker) server.RouteInitializer {
+	return func(router *mux.Router, l logrus.FieldLogger) {
+		router.HandleFunc("/healthz", handleHealthz(l)).Methods(http.MethodGet)
+		router.HandleFunc("/readyz", handleReadyz(l, c)).Methods(http.MethodGet)
+	}
+}
+
+func handleHealthz(l logrus.FieldLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		write(l, w, http.StatusOK, RestModel{Status: StatusOk})
+	}
+}
+
+func handleReadyz(l logrus.FieldLogger, c *Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rm := RestModel{Status: StatusOk, Checks: make(map[string]string)}
+		status := http.StatusOK
+		for name, err := range c.Ready(r.Context()) {
+			if err != nil {
+				l.WithError(err).Debugf("Readiness check [%s] failed.", name)
+				rm.Checks[name] = err.Error()
+				rm.Status = StatusUnavailable
+				status = http.StatusServiceUnavailable
+				continue
+			}
+			rm.Checks[name] = StatusOk
+		}
+		write(l, w, status, rm)
+	}
+}
+
+func write(l logrus.FieldLogger, w http.ResponseWriter, status int, rm RestModel) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(rm)
+	if err != nil {
+		l.WithError(err).Errorf("Writing health response.")
+	}
+}
@@ -0,0 +1,93 @@
+package invite
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// tenantLockClass distinguishes the advisory locks taken on a tenant's invites from other advisory locks.
+const tenantLockClass = int32(0x696e7669)
+
+// lockTenant takes the advisory lock serializing the creation of t's invites across every instance sharing the
+// database, holding it until the transaction db belongs to ends. The lock is re-entrant, so transactions nested within
+// one holding it take it again at once.
+func lockTenant(db *gorm.DB, t tenant.Model) error {
+	return db.Exec("SELECT pg_advisory_xact_lock(?, hashtext(?))", tenantLockClass, t.Id().String()).Error
+}
+
+// nextId allocates the tenant's next invite id from its row in invite_sequences. The row is locked by the upsert until
+// the surrounding transaction ends, so concurrent instances never allocate the same id, and it only ever advances, so
+// ids are not reused once resolved invites are pruned. A tenant's first allocation continues from any invites stored
+// before ids were sequenced.
+func nextId(db *gorm.DB, t tenant.Model) (uint32, error) {
+	var id uint32
+	err := db.Raw(`INSERT INTO invite_sequences (tenant_id, last_id)
+		SELECT ?, GREATEST(?, COALESCE(MAX(id), 0) + 1) FROM invites WHERE tenant_id = ?
+		ON CONFLICT (tenant_id) DO UPDATE SET last_id = invite_sequences.last_id + 1
+		RETURNING last_id`, t.Id(), StartInviteId, t.Id()).Scan(&id).Error
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// advanceId moves the invite id sequence of t on to at least id, so ids allocated later follow it.
+func advanceId(db *gorm.DB, t tenant.Model, id uint32) error {
+	return db.Exec(`INSERT INTO invite_sequences (tenant_id, last_id) VALUES (?, ?)
+		ON CONFLICT (tenant_id) DO UPDATE SET last_id = GREATEST(invite_sequences.last_id, EXCLUDED.last_id)`, t.Id(), id).Error
+}
+
+func create(db *gorm.DB, t tenant.Model, id uint32, originatorId uint32, worldId byte, targetId uint32, inviteType string, referenceId uint32, age time.Time, expiresAt time.Time) (Entity, error) {
+	e := Entity{
+		TenantId:     t.Id(),
+		Id:           id,
+		Region:       t.Region(),
+		MajorVersion: t.MajorVersion(),
+		MinorVersion: t.MinorVersion(),
+		InviteType:   inviteType,
+		ReferenceId:  referenceId,
+		OriginatorId: originatorId,
+		TargetId:     targetId,
+		WorldId:      worldId,
+		Age:          age,
+		ExpiresAt:    expiresAt,
+		Status:       string(StatusPending),
+	}
+	err := db.Create(&e).Error
+	if err != nil {
+		return Entity{}, err
+	}
+	return e, nil
+}
+
+func resolve(db *gorm.DB, t tenant.Model, id uint32, status Status, at time.Time) (int64, error) {
+	res := db.Model(&Entity{}).Where("tenant_id = ? AND id = ? AND status = ?", t.Id(), id, string(StatusPending)).Updates(map[string]interface{}{"status": string(status), "resolved_at": at})
+	return res.RowsAffected, res.Error
+}
+
+func deleteResolvedBefore(db *gorm.DB, before time.Time) error {
+	return db.Where("status <> ? AND resolved_at < ?", string(StatusPending), before).Delete(&Entity{}).Error
+}
+
+func putCooldown(db *gorm.DB, t tenant.Model, originatorId uint32, targetId uint32, inviteType string, expiresAt time.Time) error {
+	e := CooldownEntity{
+		TenantId:     t.Id(),
+		OriginatorId: originatorId,
+		TargetId:     targetId,
+		InviteType:   inviteType,
+		Region:       t.Region(),
+		MajorVersion: t.MajorVersion(),
+		MinorVersion: t.MinorVersion(),
+		ExpiresAt:    expiresAt,
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "originator_id"}, {Name: "target_id"}, {Name: "invite_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"expires_at"}),
+	}).Create(&e).Error
+}
+
+func deleteCooldownsExpiredAt(db *gorm.DB, now time.Time) error {
+	return db.Where("expires_at <= ?", now).Delete(&CooldownEntity{}).Error
+}
@@ -0,0 +1,102 @@
+package invite
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
+	"sync"
+)
+
+const subscriptionBuffer = 32
+
+// Update describes an invite a character received changing status. EventType is the status event type emitted for the
+// change, such as CREATED or ACCEPTED.
+type Update struct {
+	eventType string
+	invite    Model
+}
+
+func (u Update) EventType() string {
+	return u.eventType
+}
+
+func (u Update) Invite() Model {
+	return u.invite
+}
+
+type subscriptionKey struct {
+	tenantId    uuid.UUID
+	characterId uint32
+}
+
+// Broker fans updates to the invites a character received out to the subscribers of that character's inbox. Updates are
+// held in memory, so subscribers only see changes made by the instance they are connected to.
+type Broker struct {
+	lock        sync.Mutex
+	nextId      uint64
+	subscribers map[subscriptionKey]map[uint64]chan Update
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[subscriptionKey]map[uint64]chan Update)}
+}
+
+// Subscribe returns a channel receiving updates to the invites characterId receives, and a function ending the
+// subscription. A subscriber which falls too far behind has its channel closed rather than miss updates silently.
+func (b *Broker) Subscribe(t tenant.Model, characterId uint32) (<-chan Update, func()) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	k := subscriptionKey{tenantId: t.Id(), characterId: characterId}
+	if _, ok := b.subscribers[k]; !ok {
+		b.subscribers[k] = make(map[uint64]chan Update)
+	}
+	id := b.nextId
+	b.nextId++
+	c := make(chan Update, subscriptionBuffer)
+	b.subscribers[k][id] = c
+
+	return c, func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		b.remove(k, id)
+	}
+}
+
+// Publish delivers each update to the subscribers of its invite's target.
+func (b *Broker) Publish(us ...Update) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for _, u := range us {
+		k := subscriptionKey{tenantId: u.invite.Tenant().Id(), characterId: u.invite.TargetId()}
+		for id, c := range b.subscribers[k] {
+			select {
+			case c <- u:
+			default:
+				b.remove(k, id)
+			}
+		}
+	}
+}
+
+func (b *Broker) remove(k subscriptionKey, id uint64) {
+	c, ok := b.subscribers[k][id]
+	if !ok {
+		return
+	}
+	close(c)
+	delete(b.subscribers[k], id)
+	if len(b.subscribers[k]) == 0 {
+		delete(b.subscribers, k)
+	}
+}
+
+var broker *Broker
+var brokerOnce sync.Once
+
+func GetBroker() *Broker {
+	brokerOnce.Do(func() {
+		broker = NewBroker()
+	})
+	return broker
+}
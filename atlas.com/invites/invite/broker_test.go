@@ -0,0 +1,55 @@
+package invite
+
+import (
+	"testing"
+)
+
+func TestBrokerClosesSlowSubscriber(t *testing.T) {
+	tm := testTenant(t)
+	b := NewBroker()
+
+	slow, cancelSlow := b.Subscribe(tm, 2)
+	defer cancelSlow()
+	fast, cancelFast := b.Subscribe(tm, 2)
+	defer cancelFast()
+	other, cancelOther := b.Subscribe(tm, 3)
+	defer cancelOther()
+
+	u := Update{eventType: "CREATED", invite: Model{tenant: tm, id: 1, originatorId: 1, targetId: 2}}
+	for i := 0; i < subscriptionBuffer; i++ {
+		b.Publish(u)
+		<-fast
+	}
+	// the slow subscriber's buffer is now full, so the next update closes it.
+	b.Publish(u)
+
+	received := 0
+	for range slow {
+		received++
+	}
+	if received != subscriptionBuffer {
+		t.Errorf("Expected slow subscriber to receive %d updates before closing, got %d.", subscriptionBuffer, received)
+	}
+	select {
+	case _, ok := <-fast:
+		if !ok {
+			t.Fatalf("Expected subscriber keeping up to remain open.")
+		}
+	default:
+		t.Fatalf("Expected subscriber keeping up to receive the update.")
+	}
+	select {
+	case <-other:
+		t.Errorf("Expected subscriber of another character to receive nothing.")
+	default:
+	}
+
+	b.lock.Lock()
+	n := len(b.subscribers[subscriptionKey{tenantId: tm.Id(), characterId: 2}])
+	b.lock.Unlock()
+	if n != 1 {
+		t.Errorf("Expected 1 remaining subscriber, got %d.", n)
+	}
+	// ending a subscription the broker already closed is harmless.
+	cancelSlow()
+}
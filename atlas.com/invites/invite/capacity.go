@@ -0,0 +1,190 @@
+package invite
+
+import (
+	"errors"
+	"slices"
+	"sync"
+)
+
+type OverflowPolicy string
+
+const (
+	// OverflowReject refuses the new invite.
+	OverflowReject OverflowPolicy = "REJECT"
+	// OverflowEvictOldest expires the oldest pending invite to make room for the new one.
+	OverflowEvictOldest OverflowPolicy = "EVICT_OLDEST"
+	// OverflowReplace cancels the newest pending invite, so the new invite takes its place.
+	OverflowReplace OverflowPolicy = "REPLACE"
+)
+
+var ErrInvalidOverflowPolicy = errors.New("invalid overflow policy")
+
+// Limit caps a set of pending invites at max, resolving overflow as described by its OverflowPolicy. A max of zero
+// leaves the set unbounded.
+type Limit struct {
+	max      int
+	overflow OverflowPolicy
+}
+
+func (l Limit) Max() int {
+	return l.max
+}
+
+func (l Limit) Overflow() OverflowPolicy {
+	return l.overflow
+}
+
+// Exceeded reports whether a set holding count invites has no room for another.
+func (l Limit) Exceeded(count int) bool {
+	return l.max > 0 && count >= l.max
+}
+
+// CapacityPolicy bounds the invites a target may hold of each invite type, and the invites an originator may have
+// outstanding across all invite types. The zero value is unbounded.
+type CapacityPolicy struct {
+	target     Limit
+	types      map[string]Limit
+	originator Limit
+}
+
+// DefaultCapacityPolicy places no limit on the invites a target may hold or an originator may have outstanding.
+func DefaultCapacityPolicy() CapacityPolicy {
+	return CapacityPolicy{
+		target:     Limit{overflow: OverflowReject},
+		types:      make(map[string]Limit),
+		originator: Limit{overflow: OverflowReject},
+	}
+}
+
+func (p CapacityPolicy) Target(inviteType string) Limit {
+	if l, ok := p.types[inviteType]; ok {
+		return l
+	}
+	return p.target
+}
+
+func (p CapacityPolicy) Originator() Limit {
+	return p.originator
+}
+
+// evictionCandidate selects which of is, all pending invites in a set which has overflowed, l removes. It returns false
+// when l refuses the new invite instead.
+func evictionCandidate(l Limit, is []Model) (Model, bool) {
+	if len(is) == 0 || l.overflow == OverflowReject {
+		return Model{}, false
+	}
+	c := is[0]
+	for _, i := range is[1:] {
+		older := i.Age().Before(c.Age()) || (i.Age().Equal(c.Age()) && i.Id() < c.Id())
+		if older == (l.overflow == OverflowEvictOldest) {
+			c = i
+		}
+	}
+	return c, true
+}
+
+// plan returns the invites l removes from is, all pending invites in a set, to make room for one more. It returns
+// ErrCapacityExceeded when l refuses the new invite instead.
+func (l Limit) plan(is []Model) ([]Model, error) {
+	evicted := make([]Model, 0)
+	for l.Exceeded(len(is)) {
+		c, ok := evictionCandidate(l, is)
+		if !ok {
+			return nil, ErrCapacityExceeded
+		}
+		evicted = append(evicted, c)
+		is = slices.DeleteFunc(slices.Clone(is), func(i Model) bool {
+			return i.Id() == c.Id()
+		})
+	}
+	return evicted, nil
+}
+
+type LimitConfig struct {
+	Max      int    `json:"max"`
+	Overflow string `json:"overflow"`
+}
+
+type TargetCapacityConfig struct {
+	LimitConfig
+	Types map[string]LimitConfig `json:"types"`
+}
+
+type CapacityConfig struct {
+	Target     *TargetCapacityConfig `json:"target"`
+	Originator *LimitConfig          `json:"originator"`
+}
+
+// ParseCapacityPolicy builds a CapacityPolicy from a configuration. Sections absent from the configuration keep
+// DefaultCapacityPolicy, and an absent overflow policy defaults to OverflowReject.
+func ParseCapacityPolicy(c CapacityConfig) (CapacityPolicy, error) {
+	p := DefaultCapacityPolicy()
+	var err error
+	if c.Target != nil {
+		p.target, err = parseLimit(c.Target.LimitConfig)
+		if err != nil {
+			return CapacityPolicy{}, err
+		}
+		for inviteType, lc := range c.Target.Types {
+			p.types[inviteType], err = parseLimit(lc)
+			if err != nil {
+				return CapacityPolicy{}, err
+			}
+		}
+	}
+	if c.Originator != nil {
+		p.originator, err = parseLimit(*c.Originator)
+		if err != nil {
+			return CapacityPolicy{}, err
+		}
+	}
+	return p, nil
+}
+
+func parseLimit(c LimitConfig) (Limit, error) {
+	l := Limit{max: c.Max, overflow: OverflowPolicy(c.Overflow)}
+	if l.overflow == "" {
+		l.overflow = OverflowReject
+	}
+	switch l.overflow {
+	case OverflowReject, OverflowEvictOldest, OverflowReplace:
+		return l, nil
+	}
+	return Limit{}, ErrInvalidOverflowPolicy
+}
+
+// CapacityPolicyFromEnv reads INVITE_CAPACITY_CONFIG with loadJSONConfig. When it is unset DefaultCapacityPolicy
+// applies.
+func CapacityPolicyFromEnv() (CapacityPolicy, error) {
+	var c CapacityConfig
+	ok, err := loadJSONConfig("INVITE_CAPACITY_CONFIG", &c)
+	if err != nil {
+		return CapacityPolicy{}, err
+	}
+	if !ok {
+		return DefaultCapacityPolicy(), nil
+	}
+	return ParseCapacityPolicy(c)
+}
+
+var capacityPolicy CapacityPolicy
+var capacityOnce sync.Once
+
+// InitCapacityPolicy sets the CapacityPolicy used by the service, and panics if one is already set.
+func InitCapacityPolicy(p CapacityPolicy) {
+	set := false
+	capacityOnce.Do(func() {
+		capacityPolicy = p
+		set = true
+	})
+	if !set {
+		panic("invite capacity policy already set")
+	}
+}
+
+func GetCapacityPolicy() CapacityPolicy {
+	capacityOnce.Do(func() {
+		capacityPolicy = DefaultCapacityPolicy()
+	})
+	return capacityPolicy
+}
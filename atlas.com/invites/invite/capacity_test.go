@@ -0,0 +1,75 @@
+package invite
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPlanEvictionsCapacity(t *testing.T) {
+	now := time.Now()
+	// pending invites, listed out of age order, where invite 2 is the oldest and invite 3 the newest.
+	is := []Model{
+		{id: 1, age: now.Add(-2 * time.Second)},
+		{id: 2, age: now.Add(-3 * time.Second)},
+		{id: 3, age: now},
+		{id: 4, age: now.Add(-1 * time.Second)},
+	}
+	target := func(l Limit) CapacityPolicy {
+		return CapacityPolicy{target: l, originator: Limit{overflow: OverflowReject}}
+	}
+	originator := func(l Limit) CapacityPolicy {
+		return CapacityPolicy{target: Limit{overflow: OverflowReject}, originator: l}
+	}
+
+	tests := []struct {
+		name       string
+		cp         CapacityPolicy
+		targeted   []Model
+		originated []Model
+		evicted    []uint32
+		cause      EvictionCause
+		err        error
+	}{
+		{"unlimited", DefaultCapacityPolicy(), is, is, nil, "", nil},
+		{"reject below cap", target(Limit{max: 5, overflow: OverflowReject}), is, nil, nil, "", nil},
+		{"reject at cap", target(Limit{max: 4, overflow: OverflowReject}), is, nil, nil, "", ErrCapacityExceeded},
+		{"reject over cap", target(Limit{max: 2, overflow: OverflowReject}), is, nil, nil, "", ErrCapacityExceeded},
+		{"originator reject at cap", originator(Limit{max: 4, overflow: OverflowReject}), nil, is, nil, "", ErrCapacityExceeded},
+		{"evict oldest below cap", target(Limit{max: 5, overflow: OverflowEvictOldest}), is, nil, nil, "", nil},
+		{"evict oldest at cap", target(Limit{max: 4, overflow: OverflowEvictOldest}), is, nil, []uint32{2}, EvictionOldest, nil},
+		{"evict oldest over cap", target(Limit{max: 2, overflow: OverflowEvictOldest}), is, nil, []uint32{2, 1, 4}, EvictionOldest, nil},
+		{"evict oldest ties by id", target(Limit{max: 2, overflow: OverflowEvictOldest}), []Model{{id: 6, age: now}, {id: 5, age: now}}, nil, []uint32{5}, EvictionOldest, nil},
+		{"originator evict oldest at cap", originator(Limit{max: 4, overflow: OverflowEvictOldest}), nil, is, []uint32{2}, EvictionOldest, nil},
+		{"replace at cap", target(Limit{max: 4, overflow: OverflowReplace}), is, nil, []uint32{3}, EvictionReplaced, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es, err := planEvictions(ExclusivityPolicy{}, tt.cp, "BUDDY", nil, tt.targeted, tt.originated)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error [%v], got [%v].", tt.err, err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if len(es) != len(tt.evicted) {
+				t.Fatalf("Expected %d evictions, got %d.", len(tt.evicted), len(es))
+			}
+			for i, id := range tt.evicted {
+				if es[i].Invite().Id() != id || es[i].Cause() != tt.cause {
+					t.Errorf("Expected eviction %d to be invite [%d] for [%s], got [%d] for [%s].", i, id, tt.cause, es[i].Invite().Id(), es[i].Cause())
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultCapacityPolicyUnlimited(t *testing.T) {
+	p := DefaultCapacityPolicy()
+	if p.Target("BUDDY").Exceeded(1000) {
+		t.Errorf("Expected default target limit to be unlimited.")
+	}
+	if p.Originator().Exceeded(1000) {
+		t.Errorf("Expected default originator limit to be unlimited.")
+	}
+}
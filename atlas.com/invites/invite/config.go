@@ -0,0 +1,30 @@
+package invite
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// loadJSONConfig decodes the configuration held by env into dst. The variable holds either inline JSON or the path to a
+// JSON file. It reports false, leaving dst untouched, when the variable is unset.
+func loadJSONConfig(env string, dst any) (bool, error) {
+	val, ok := os.LookupEnv(env)
+	if !ok || val == "" {
+		return false, nil
+	}
+
+	data := []byte(val)
+	if !strings.HasPrefix(strings.TrimSpace(val), "{") {
+		var err error
+		data, err = os.ReadFile(val)
+		if err != nil {
+			return false, err
+		}
+	}
+	err := json.Unmarshal(data, dst)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,130 @@
+package invite
+
+import (
+	invite2 "atlas-invites/kafka/message/invite"
+	"github.com/Chronicle20/atlas-tenant"
+	"sync"
+	"time"
+)
+
+// Cooldown prevents an originator from re-inviting a target which rejected an invite of the same type until it
+// expires.
+type Cooldown struct {
+	tenant       tenant.Model
+	originatorId uint32
+	targetId     uint32
+	inviteType   string
+	expiresAt    time.Time
+}
+
+func (c Cooldown) Tenant() tenant.Model {
+	return c.tenant
+}
+
+func (c Cooldown) OriginatorId() uint32 {
+	return c.originatorId
+}
+
+func (c Cooldown) TargetId() uint32 {
+	return c.targetId
+}
+
+func (c Cooldown) Type() string {
+	return c.inviteType
+}
+
+func (c Cooldown) ExpiresAt() time.Time {
+	return c.expiresAt
+}
+
+// Remaining returns how long the cooldown has left to run, or zero once it has expired.
+func (c Cooldown) Remaining() time.Duration {
+	d := time.Until(c.expiresAt)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// ReinvitePolicy resolves how long an originator must wait after a rejection before inviting the same target to the
+// same invite type again. A zero duration disables the cooldown.
+type ReinvitePolicy struct {
+	defaultCooldown time.Duration
+	types           map[string]time.Duration
+}
+
+// DefaultReinvitePolicy applies a 5 minute cooldown to rejected BUDDY and GUILD invites.
+func DefaultReinvitePolicy() ReinvitePolicy {
+	return ReinvitePolicy{
+		types: map[string]time.Duration{
+			invite2.InviteTypeBuddy: 5 * time.Minute,
+			invite2.InviteTypeGuild: 5 * time.Minute,
+		},
+	}
+}
+
+func (p ReinvitePolicy) Cooldown(inviteType string) time.Duration {
+	if d, ok := p.types[inviteType]; ok {
+		return d
+	}
+	return p.defaultCooldown
+}
+
+type ReinviteConfig struct {
+	Default string            `json:"default"`
+	Types   map[string]string `json:"types"`
+}
+
+// ParseReinvitePolicy builds a ReinvitePolicy from a configuration whose durations are expressed as Go duration
+// strings. The configuration replaces DefaultReinvitePolicy entirely.
+func ParseReinvitePolicy(c ReinviteConfig) (ReinvitePolicy, error) {
+	p := ReinvitePolicy{}
+	var err error
+	if c.Default != "" {
+		p.defaultCooldown, err = time.ParseDuration(c.Default)
+		if err != nil {
+			return ReinvitePolicy{}, err
+		}
+	}
+	p.types, err = parseTypeTtls(c.Types)
+	if err != nil {
+		return ReinvitePolicy{}, err
+	}
+	return p, nil
+}
+
+// ReinvitePolicyFromEnv reads INVITE_REINVITE_COOLDOWN_CONFIG with loadJSONConfig. When it is unset
+// DefaultReinvitePolicy applies.
+func ReinvitePolicyFromEnv() (ReinvitePolicy, error) {
+	var c ReinviteConfig
+	ok, err := loadJSONConfig("INVITE_REINVITE_COOLDOWN_CONFIG", &c)
+	if err != nil {
+		return ReinvitePolicy{}, err
+	}
+	if !ok {
+		return DefaultReinvitePolicy(), nil
+	}
+	return ParseReinvitePolicy(c)
+}
+
+var reinvitePolicy ReinvitePolicy
+var reinviteOnce sync.Once
+
+// InitReinvitePolicy sets the ReinvitePolicy used by the service, and panics if one is already set.
+func InitReinvitePolicy(p ReinvitePolicy) {
+	set := false
+	reinviteOnce.Do(func() {
+		reinvitePolicy = p
+		set = true
+	})
+	if !set {
+		panic("invite re-invite policy already set")
+	}
+}
+
+func GetReinvitePolicy() ReinvitePolicy {
+	reinviteOnce.Do(func() {
+		reinvitePolicy = DefaultReinvitePolicy()
+	})
+	return reinvitePolicy
+}
@@ -0,0 +1,86 @@
+package invite
+
+import (
+	invite2 "atlas-invites/kafka/message/invite"
+	"errors"
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
+	"testing"
+	"time"
+)
+
+func TestCooldownExpiry(t *testing.T) {
+	tm := testTenant(t)
+	r := NewInMemoryRegistry()
+
+	_ = r.AddCooldown(tm, 1, 2, invite2.InviteTypeBuddy, time.Now().Add(time.Minute))
+	_ = r.AddCooldown(tm, 1, 2, invite2.InviteTypeGuild, time.Now().Add(-time.Second))
+
+	cs, err := r.GetCooldowns(tm, 1, 2)
+	if err != nil {
+		t.Fatalf("Unable to get cooldowns: %v", err)
+	}
+	if len(cs) != 1 || cs[0].Type() != invite2.InviteTypeBuddy {
+		t.Fatalf("Expected only the [%s] cooldown to be active, got %d cooldowns.", invite2.InviteTypeBuddy, len(cs))
+	}
+	if cs[0].Remaining() <= 0 || cs[0].Remaining() > time.Minute {
+		t.Errorf("Expected remaining cooldown within a minute, got %s.", cs[0].Remaining())
+	}
+
+	cs, err = r.GetCooldowns(tm, 2, 1)
+	if err != nil {
+		t.Fatalf("Unable to get cooldowns: %v", err)
+	}
+	if len(cs) != 0 {
+		t.Errorf("Expected cooldowns to apply only from originator to target, got %d.", len(cs))
+	}
+
+	err = r.DeleteExpiredCooldowns()
+	if err != nil {
+		t.Fatalf("Unable to delete expired cooldowns: %v", err)
+	}
+	if len(r.cooldowns) != 1 {
+		t.Errorf("Expected 1 cooldown to be kept, got %d.", len(r.cooldowns))
+	}
+}
+
+func TestCreateRefusedDuringCooldown(t *testing.T) {
+	_, ctx, p := testProcessor(t)
+	tm := tenant.MustFromContext(ctx)
+
+	_, err := p.CreateAndEmit(1, 0, invite2.InviteTypeBuddy, 1, 2, uuid.New())
+	if err != nil {
+		t.Fatalf("Unable to create invite: %v", err)
+	}
+	_, err = p.RejectAndEmit(1, 0, invite2.InviteTypeBuddy, 2, uuid.New())
+	if err != nil {
+		t.Fatalf("Unable to reject invite: %v", err)
+	}
+
+	_, err = p.CreateAndEmit(1, 0, invite2.InviteTypeBuddy, 1, 2, uuid.New())
+	if !errors.Is(err, ErrCooldown) {
+		t.Fatalf("Expected [%v], got [%v].", ErrCooldown, err)
+	}
+	// the cooldown applies only to the rejected invite type and direction.
+	_, err = p.CreateAndEmit(1, 0, invite2.InviteTypeParty, 1, 2, uuid.New())
+	if err != nil {
+		t.Errorf("Unable to create invite of another type: %v", err)
+	}
+	_, err = p.CreateAndEmit(2, 0, invite2.InviteTypeBuddy, 2, 1, uuid.New())
+	if err != nil {
+		t.Errorf("Unable to create invite from the target: %v", err)
+	}
+
+	err = GetRegistry().AddCooldown(tm, 1, 2, invite2.InviteTypeBuddy, time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatalf("Unable to expire cooldown: %v", err)
+	}
+	// reciprocates the invite from the target, now the cooldown has expired.
+	m, err := p.CreateAndEmit(1, 0, invite2.InviteTypeBuddy, 1, 2, uuid.New())
+	if err != nil {
+		t.Fatalf("Unable to create invite once the cooldown expired: %v", err)
+	}
+	if m.Status() != StatusAccepted {
+		t.Errorf("Expected reciprocal invite to be accepted, got [%s].", m.Status())
+	}
+}
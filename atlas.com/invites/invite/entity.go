@@ -0,0 +1,102 @@
+package invite
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+func Migration(db *gorm.DB) error {
+	return db.AutoMigrate(&Entity{}, &CooldownEntity{}, &SequenceEntity{})
+}
+
+type Entity struct {
+	TenantId     uuid.UUID  `gorm:"primaryKey;type:uuid;not null;index:idx_invites_target,priority:1;index:idx_invites_originator,priority:1;index:idx_invites_reference,priority:1"`
+	Id           uint32     `gorm:"primaryKey;autoIncrement:false;not null"`
+	Region       string     `gorm:"not null"`
+	MajorVersion uint16     `gorm:"not null"`
+	MinorVersion uint16     `gorm:"not null"`
+	InviteType   string     `gorm:"not null;index:idx_invites_target,priority:3;index:idx_invites_reference,priority:2"`
+	ReferenceId  uint32     `gorm:"not null;index:idx_invites_reference,priority:3"`
+	OriginatorId uint32     `gorm:"not null;index:idx_invites_originator,priority:2"`
+	TargetId     uint32     `gorm:"not null;index:idx_invites_target,priority:2"`
+	WorldId      byte       `gorm:"not null"`
+	Age          time.Time  `gorm:"not null"`
+	ExpiresAt    time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP;index"`
+	Status       string     `gorm:"not null;default:PENDING;index"`
+	ResolvedAt   *time.Time `gorm:"index"`
+}
+
+func (e Entity) TableName() string {
+	return "invites"
+}
+
+func Make(e Entity) (Model, error) {
+	t, err := tenant.Create(e.TenantId, e.Region, e.MajorVersion, e.MinorVersion)
+	if err != nil {
+		return Model{}, err
+	}
+	return Model{
+		tenant:       t,
+		id:           e.Id,
+		inviteType:   e.InviteType,
+		referenceId:  e.ReferenceId,
+		originatorId: e.OriginatorId,
+		targetId:     e.TargetId,
+		worldId:      e.WorldId,
+		age:          e.Age,
+		expiresAt:    e.ExpiresAt,
+		status:       Status(e.Status),
+		history:      makeHistory(e),
+	}, nil
+}
+
+// makeHistory rebuilds an invite's transitions. Every status other than PENDING is terminal, so they follow from when
+// the invite was created and when, if ever, it was resolved.
+func makeHistory(e Entity) []Transition {
+	results := []Transition{{status: StatusPending, at: e.Age}}
+	if Status(e.Status) != StatusPending && e.ResolvedAt != nil {
+		results = append(results, Transition{status: Status(e.Status), at: *e.ResolvedAt})
+	}
+	return results
+}
+
+// SequenceEntity holds the last invite id allocated for a tenant.
+type SequenceEntity struct {
+	TenantId uuid.UUID `gorm:"primaryKey;type:uuid;not null"`
+	LastId   uint32    `gorm:"not null"`
+}
+
+func (e SequenceEntity) TableName() string {
+	return "invite_sequences"
+}
+
+type CooldownEntity struct {
+	TenantId     uuid.UUID `gorm:"primaryKey;type:uuid;not null"`
+	OriginatorId uint32    `gorm:"primaryKey;autoIncrement:false;not null"`
+	TargetId     uint32    `gorm:"primaryKey;autoIncrement:false;not null"`
+	InviteType   string    `gorm:"primaryKey;not null"`
+	Region       string    `gorm:"not null"`
+	MajorVersion uint16    `gorm:"not null"`
+	MinorVersion uint16    `gorm:"not null"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+}
+
+func (e CooldownEntity) TableName() string {
+	return "invite_cooldowns"
+}
+
+func MakeCooldown(e CooldownEntity) (Cooldown, error) {
+	t, err := tenant.Create(e.TenantId, e.Region, e.MajorVersion, e.MinorVersion)
+	if err != nil {
+		return Cooldown{}, err
+	}
+	return Cooldown{
+		tenant:       t,
+		originatorId: e.OriginatorId,
+		targetId:     e.TargetId,
+		inviteType:   e.InviteType,
+		expiresAt:    e.ExpiresAt,
+	}, nil
+}
@@ -0,0 +1,51 @@
+package invite
+
+import (
+	invite2 "atlas-invites/kafka/message/invite"
+	"errors"
+	"slices"
+)
+
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrAlreadyPending      = errors.New("invite already pending")
+	ErrSelfInvite          = errors.New("cannot invite self")
+	ErrInvalidType         = errors.New("invalid invite type")
+	ErrBlocked             = errors.New("originator is blocked by target")
+	ErrRateLimited         = errors.New("invite rate limit exceeded")
+	ErrCooldown            = errors.New("target recently rejected an invite of this type")
+	ErrCapacityExceeded    = errors.New("pending invite limit reached")
+	ErrExclusivityConflict = errors.New("character already has an outstanding invite of this type")
+	ErrInvalidTransition   = errors.New("invalid invite status transition")
+	// ErrPreferenceDeclined is reported as a REJECTED event rather than an ERROR event.
+	ErrPreferenceDeclined = errors.New("target declines invites of this type")
+)
+
+// Reason maps an error produced while processing a command to the reason code reported in an ERROR status event.
+func Reason(err error) string {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return invite2.ErrorReasonNotFound
+	case errors.Is(err, ErrAlreadyPending):
+		return invite2.ErrorReasonAlreadyPending
+	case errors.Is(err, ErrSelfInvite):
+		return invite2.ErrorReasonSelfInvite
+	case errors.Is(err, ErrInvalidType):
+		return invite2.ErrorReasonInvalidType
+	case errors.Is(err, ErrBlocked):
+		return invite2.ErrorReasonBlocked
+	case errors.Is(err, ErrRateLimited):
+		return invite2.ErrorReasonRateLimited
+	case errors.Is(err, ErrCooldown):
+		return invite2.ErrorReasonCooldown
+	case errors.Is(err, ErrExclusivityConflict):
+		return invite2.ErrorReasonExclusivityConflict
+	case errors.Is(err, ErrCapacityExceeded):
+		return invite2.ErrorReasonCapacityExceeded
+	}
+	return invite2.ErrorReasonUnknown
+}
+
+func validType(inviteType string) bool {
+	return slices.Contains(invite2.InviteTypes, inviteType)
+}
@@ -0,0 +1,35 @@
+package invite
+
+import (
+	invite2 "atlas-invites/kafka/message/invite"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestReason(t *testing.T) {
+	tests := []struct {
+		err    error
+		reason string
+	}{
+		{ErrNotFound, invite2.ErrorReasonNotFound},
+		{ErrAlreadyPending, invite2.ErrorReasonAlreadyPending},
+		{ErrSelfInvite, invite2.ErrorReasonSelfInvite},
+		{ErrInvalidType, invite2.ErrorReasonInvalidType},
+		{ErrBlocked, invite2.ErrorReasonBlocked},
+		{ErrRateLimited, invite2.ErrorReasonRateLimited},
+		{ErrCooldown, invite2.ErrorReasonCooldown},
+		{ErrExclusivityConflict, invite2.ErrorReasonExclusivityConflict},
+		{ErrCapacityExceeded, invite2.ErrorReasonCapacityExceeded},
+		{fmt.Errorf("unable to accept invite: %w", ErrNotFound), invite2.ErrorReasonNotFound},
+		{ErrInvalidTransition, invite2.ErrorReasonUnknown},
+		{errors.New("connection refused"), invite2.ErrorReasonUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			if r := Reason(tt.err); r != tt.reason {
+				t.Errorf("Expected reason [%s], got [%s].", tt.reason, r)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package invite
+
+import (
+	"slices"
+)
+
+type EvictionCause string
+
+const (
+	// EvictionSuperseded removes an invite which conflicts with a new invite of an exclusive type.
+	EvictionSuperseded EvictionCause = "SUPERSEDED"
+	// EvictionOldest removes the oldest invite under an OverflowEvictOldest limit.
+	EvictionOldest EvictionCause = "OLDEST"
+	// EvictionReplaced removes the newest invite under an OverflowReplace limit.
+	EvictionReplaced EvictionCause = "REPLACED"
+)
+
+// Status returns the status an invite evicted for cause is resolved to.
+func (c EvictionCause) Status() Status {
+	switch c {
+	case EvictionOldest:
+		return StatusExpired
+	case EvictionReplaced:
+		return StatusCancelled
+	}
+	return StatusSuperseded
+}
+
+// Eviction describes an invite resolved to make room for a new one, and why it was resolved.
+type Eviction struct {
+	invite Model
+	cause  EvictionCause
+}
+
+func (e Eviction) Invite() Model {
+	return e.invite
+}
+
+func (e Eviction) Cause() EvictionCause {
+	return e.cause
+}
+
+func overflowCause(o OverflowPolicy) EvictionCause {
+	if o == OverflowReplace {
+		return EvictionReplaced
+	}
+	return EvictionOldest
+}
+
+// planEvictions resolves the evictions needed before an originator may send a target a new invite of inviteType.
+// involved holds the pending invites of inviteType sent or received by either character, targeted those of inviteType
+// the target holds, and originated those the originator has outstanding. Exclusivity is resolved first, and the
+// capacity limits are then applied to what remains. Nothing is evicted unless every rule can be met.
+func planEvictions(xp ExclusivityPolicy, cp CapacityPolicy, inviteType string, involved []Model, targeted []Model, originated []Model) ([]Eviction, error) {
+	results := make([]Eviction, 0)
+	if len(involved) > 0 {
+		switch xp.Rule(inviteType) {
+		case ExclusivityRefuse:
+			return nil, ErrExclusivityConflict
+		case ExclusivitySupersede:
+			for _, i := range involved {
+				results = append(results, Eviction{invite: i, cause: EvictionSuperseded})
+			}
+		}
+	}
+
+	tl := cp.Target(inviteType)
+	te, err := tl.plan(remaining(targeted, results))
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range te {
+		results = append(results, Eviction{invite: i, cause: overflowCause(tl.Overflow())})
+	}
+
+	ol := cp.Originator()
+	oe, err := ol.plan(remaining(originated, results))
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range oe {
+		results = append(results, Eviction{invite: i, cause: overflowCause(ol.Overflow())})
+	}
+	return results, nil
+}
+
+// remaining returns the invites in is which es does not evict.
+func remaining(is []Model, es []Eviction) []Model {
+	return slices.DeleteFunc(slices.Clone(is), func(i Model) bool {
+		return slices.ContainsFunc(es, func(e Eviction) bool {
+			return e.Invite().Id() == i.Id()
+		})
+	})
+}
@@ -0,0 +1,98 @@
+package invite
+
+import (
+	invite2 "atlas-invites/kafka/message/invite"
+	"errors"
+	"sync"
+)
+
+type ExclusivityRule string
+
+const (
+	// ExclusivityNone allows a character any number of outstanding invites of a type.
+	ExclusivityNone ExclusivityRule = "NONE"
+	// ExclusivitySupersede cancels a character's outstanding invite of a type when a new one involving it is created.
+	ExclusivitySupersede ExclusivityRule = "SUPERSEDE"
+	// ExclusivityRefuse refuses a new invite involving a character which already has an outstanding invite of a type.
+	ExclusivityRefuse ExclusivityRule = "REFUSE"
+)
+
+var ErrInvalidExclusivityRule = errors.New("invalid exclusivity rule")
+
+// ExclusivityPolicy resolves which invite types a character may have at most one of outstanding, whether as originator
+// or target, and how a conflicting new invite is handled.
+type ExclusivityPolicy struct {
+	types map[string]ExclusivityRule
+}
+
+// DefaultExclusivityPolicy makes TRADE exclusive, with a new invite superseding any outstanding one.
+func DefaultExclusivityPolicy() ExclusivityPolicy {
+	return ExclusivityPolicy{
+		types: map[string]ExclusivityRule{
+			invite2.InviteTypeTrade: ExclusivitySupersede,
+		},
+	}
+}
+
+func (p ExclusivityPolicy) Rule(inviteType string) ExclusivityRule {
+	if r, ok := p.types[inviteType]; ok {
+		return r
+	}
+	return ExclusivityNone
+}
+
+func (p ExclusivityPolicy) Exclusive(inviteType string) bool {
+	return p.Rule(inviteType) != ExclusivityNone
+}
+
+// ParseExclusivityPolicy builds an ExclusivityPolicy from a configuration mapping invite types to rules. The
+// configuration replaces DefaultExclusivityPolicy entirely.
+func ParseExclusivityPolicy(c map[string]string) (ExclusivityPolicy, error) {
+	p := ExclusivityPolicy{types: make(map[string]ExclusivityRule)}
+	for inviteType, v := range c {
+		r := ExclusivityRule(v)
+		switch r {
+		case ExclusivityNone, ExclusivitySupersede, ExclusivityRefuse:
+			p.types[inviteType] = r
+		default:
+			return ExclusivityPolicy{}, ErrInvalidExclusivityRule
+		}
+	}
+	return p, nil
+}
+
+// ExclusivityPolicyFromEnv reads INVITE_EXCLUSIVITY_CONFIG with loadJSONConfig. When it is unset
+// DefaultExclusivityPolicy applies.
+func ExclusivityPolicyFromEnv() (ExclusivityPolicy, error) {
+	var c map[string]string
+	ok, err := loadJSONConfig("INVITE_EXCLUSIVITY_CONFIG", &c)
+	if err != nil {
+		return ExclusivityPolicy{}, err
+	}
+	if !ok {
+		return DefaultExclusivityPolicy(), nil
+	}
+	return ParseExclusivityPolicy(c)
+}
+
+var exclusivityPolicy ExclusivityPolicy
+var exclusivityOnce sync.Once
+
+// InitExclusivityPolicy sets the ExclusivityPolicy used by the service, and panics if one is already set.
+func InitExclusivityPolicy(p ExclusivityPolicy) {
+	set := false
+	exclusivityOnce.Do(func() {
+		exclusivityPolicy = p
+		set = true
+	})
+	if !set {
+		panic("invite exclusivity policy already set")
+	}
+}
+
+func GetExclusivityPolicy() ExclusivityPolicy {
+	exclusivityOnce.Do(func() {
+		exclusivityPolicy = DefaultExclusivityPolicy()
+	})
+	return exclusivityPolicy
+}
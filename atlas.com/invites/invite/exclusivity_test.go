@@ -0,0 +1,99 @@
+package invite
+
+import (
+	invite2 "atlas-invites/kafka/message/invite"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDefaultExclusivityPolicy(t *testing.T) {
+	p := DefaultExclusivityPolicy()
+	if r := p.Rule(invite2.InviteTypeTrade); r != ExclusivitySupersede {
+		t.Errorf("Expected [%s] rule [%s], got [%s].", invite2.InviteTypeTrade, ExclusivitySupersede, r)
+	}
+	for _, inviteType := range []string{invite2.InviteTypeBuddy, invite2.InviteTypeParty, invite2.InviteTypeGuild} {
+		if p.Exclusive(inviteType) {
+			t.Errorf("Expected [%s] not to be exclusive.", inviteType)
+		}
+	}
+}
+
+func TestCreateSupersedesExclusiveInvite(t *testing.T) {
+	tm := testTenant(t)
+	r := NewInMemoryRegistry()
+	xp := DefaultExclusivityPolicy()
+	cp := DefaultCapacityPolicy()
+
+	a, _, err := r.Create(tm, 1, 0, 2, invite2.InviteTypeTrade, 1, time.Now(), time.Minute, xp, cp)
+	if err != nil {
+		t.Fatalf("Unable to create invite: %v", err)
+	}
+	// unrelated to the new invite, so left pending.
+	b, _, err := r.Create(tm, 4, 0, 5, invite2.InviteTypeTrade, 4, time.Now(), time.Minute, xp, cp)
+	if err != nil {
+		t.Fatalf("Unable to create invite: %v", err)
+	}
+
+	// involves character 2, the target of a.
+	c, es, err := r.Create(tm, 3, 0, 2, invite2.InviteTypeTrade, 3, time.Now(), time.Minute, xp, cp)
+	if err != nil {
+		t.Fatalf("Unable to create invite: %v", err)
+	}
+	if len(es) != 1 {
+		t.Fatalf("Expected 1 eviction, got %d.", len(es))
+	}
+	if es[0].Invite().Id() != a.Id() || es[0].Cause() != EvictionSuperseded {
+		t.Errorf("Expected invite [%d] to be evicted for [%s], got [%d] for [%s].", a.Id(), EvictionSuperseded, es[0].Invite().Id(), es[0].Cause())
+	}
+	if es[0].Invite().Status() != StatusSuperseded {
+		t.Errorf("Expected evicted invite status [%s], got [%s].", StatusSuperseded, es[0].Invite().Status())
+	}
+
+	for _, tc := range []struct {
+		id     uint32
+		status Status
+	}{{a.Id(), StatusSuperseded}, {b.Id(), StatusPending}, {c.Id(), StatusPending}} {
+		m, err := r.GetById(tm, tc.id)
+		if err != nil {
+			t.Fatalf("Unable to get invite [%d]: %v", tc.id, err)
+		}
+		if m.Status() != tc.status {
+			t.Errorf("Expected invite [%d] status [%s], got [%s].", tc.id, tc.status, m.Status())
+		}
+	}
+}
+
+func TestCreateRefusesExclusiveInvite(t *testing.T) {
+	tm := testTenant(t)
+	r := NewInMemoryRegistry()
+	xp, err := ParseExclusivityPolicy(map[string]string{invite2.InviteTypeTrade: string(ExclusivityRefuse)})
+	if err != nil {
+		t.Fatalf("Unable to parse exclusivity policy: %v", err)
+	}
+	cp := DefaultCapacityPolicy()
+
+	a, _, err := r.Create(tm, 1, 0, 2, invite2.InviteTypeTrade, 1, time.Now(), time.Minute, xp, cp)
+	if err != nil {
+		t.Fatalf("Unable to create invite: %v", err)
+	}
+	// involves character 1, the originator of a.
+	_, _, err = r.Create(tm, 3, 0, 1, invite2.InviteTypeTrade, 3, time.Now(), time.Minute, xp, cp)
+	if !errors.Is(err, ErrExclusivityConflict) {
+		t.Fatalf("Expected [%v], got [%v].", ErrExclusivityConflict, err)
+	}
+	m, err := r.GetById(tm, a.Id())
+	if err != nil {
+		t.Fatalf("Unable to get invite: %v", err)
+	}
+	if !m.Pending() {
+		t.Errorf("Expected invite [%d] to remain pending, got [%s].", m.Id(), m.Status())
+	}
+	is, err := r.GetForCharacter(tm, 1)
+	if err != nil {
+		t.Fatalf("Unable to get invites: %v", err)
+	}
+	if len(is) != 0 {
+		t.Errorf("Expected no invites for character 1, got %d.", len(is))
+	}
+}
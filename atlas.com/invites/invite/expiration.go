@@ -0,0 +1,179 @@
+package invite
+
+import (
+	"errors"
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTtl       = 180 * time.Second
+	DefaultRetention = 10 * time.Minute
+)
+
+var (
+	ErrInvalidTtl       = errors.New("invite time to live must be positive")
+	ErrInvalidRetention = errors.New("invite retention must be positive")
+)
+
+// ExpirationPolicy resolves how long an invite lives. A tenant and invite type specific value takes precedence over a
+// tenant default, which in turn takes precedence over the invite type and global defaults.
+type ExpirationPolicy struct {
+	defaultTtl time.Duration
+	types      map[string]time.Duration
+	tenants    map[uuid.UUID]tenantExpirationPolicy
+}
+
+type tenantExpirationPolicy struct {
+	defaultTtl time.Duration
+	types      map[string]time.Duration
+}
+
+func NewExpirationPolicy(defaultTtl time.Duration) ExpirationPolicy {
+	return ExpirationPolicy{
+		defaultTtl: defaultTtl,
+		types:      make(map[string]time.Duration),
+		tenants:    make(map[uuid.UUID]tenantExpirationPolicy),
+	}
+}
+
+func (p ExpirationPolicy) Ttl(t tenant.Model, inviteType string) time.Duration {
+	if tp, ok := p.tenants[t.Id()]; ok {
+		if ttl, ok := tp.types[inviteType]; ok {
+			return ttl
+		}
+		if tp.defaultTtl > 0 {
+			return tp.defaultTtl
+		}
+	}
+	if ttl, ok := p.types[inviteType]; ok {
+		return ttl
+	}
+	return p.defaultTtl
+}
+
+type ExpirationConfig struct {
+	Default string                            `json:"default"`
+	Types   map[string]string                 `json:"types"`
+	Tenants map[string]TenantExpirationConfig `json:"tenants"`
+}
+
+type TenantExpirationConfig struct {
+	Default string            `json:"default"`
+	Types   map[string]string `json:"types"`
+}
+
+// ParseExpirationPolicy builds an ExpirationPolicy from a configuration whose durations are expressed as Go duration
+// strings (e.g. "20s", "5m").
+func ParseExpirationPolicy(c ExpirationConfig) (ExpirationPolicy, error) {
+	p := NewExpirationPolicy(DefaultTtl)
+	var err error
+	if c.Default != "" {
+		p.defaultTtl, err = parseTtl(c.Default)
+		if err != nil {
+			return ExpirationPolicy{}, err
+		}
+	}
+	p.types, err = parseTypeTtls(c.Types)
+	if err != nil {
+		return ExpirationPolicy{}, err
+	}
+	for k, tc := range c.Tenants {
+		var tenantId uuid.UUID
+		tenantId, err = uuid.Parse(k)
+		if err != nil {
+			return ExpirationPolicy{}, err
+		}
+		tp := tenantExpirationPolicy{}
+		if tc.Default != "" {
+			tp.defaultTtl, err = parseTtl(tc.Default)
+			if err != nil {
+				return ExpirationPolicy{}, err
+			}
+		}
+		tp.types, err = parseTypeTtls(tc.Types)
+		if err != nil {
+			return ExpirationPolicy{}, err
+		}
+		p.tenants[tenantId] = tp
+	}
+	return p, nil
+}
+
+func parseTypeTtls(c map[string]string) (map[string]time.Duration, error) {
+	results := make(map[string]time.Duration)
+	for k, v := range c {
+		ttl, err := parseTtl(v)
+		if err != nil {
+			return nil, err
+		}
+		results[k] = ttl
+	}
+	return results, nil
+}
+
+func parseTtl(val string) (time.Duration, error) {
+	ttl, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, err
+	}
+	if ttl <= 0 {
+		return 0, ErrInvalidTtl
+	}
+	return ttl, nil
+}
+
+// ExpirationPolicyFromEnv reads INVITE_TTL_CONFIG with loadJSONConfig. When it is unset every invite uses DefaultTtl.
+func ExpirationPolicyFromEnv() (ExpirationPolicy, error) {
+	var c ExpirationConfig
+	ok, err := loadJSONConfig("INVITE_TTL_CONFIG", &c)
+	if err != nil {
+		return ExpirationPolicy{}, err
+	}
+	if !ok {
+		return NewExpirationPolicy(DefaultTtl), nil
+	}
+	return ParseExpirationPolicy(c)
+}
+
+// RetentionFromEnv reads INVITE_RETENTION, how long resolved invites remain queryable. When it is unset
+// DefaultRetention applies.
+func RetentionFromEnv() (time.Duration, error) {
+	val, ok := os.LookupEnv("INVITE_RETENTION")
+	if !ok || val == "" {
+		return DefaultRetention, nil
+	}
+	retention, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, err
+	}
+	if retention <= 0 {
+		return 0, ErrInvalidRetention
+	}
+	return retention, nil
+}
+
+var expirationPolicy ExpirationPolicy
+var expirationOnce sync.Once
+
+// InitExpirationPolicy sets the ExpirationPolicy used by the service, and panics if one is already set.
+func InitExpirationPolicy(p ExpirationPolicy) {
+	set := false
+	expirationOnce.Do(func() {
+		expirationPolicy = p
+		set = true
+	})
+	if !set {
+		panic("invite expiration policy already set")
+	}
+}
+
+func GetExpirationPolicy() ExpirationPolicy {
+	expirationOnce.Do(func() {
+		expirationPolicy = NewExpirationPolicy(DefaultTtl)
+	})
+	return expirationPolicy
+}
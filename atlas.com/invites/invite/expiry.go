@@ -0,0 +1,92 @@
+package invite
+
+import (
+	"container/heap"
+	"github.com/Chronicle20/atlas-tenant"
+	"sync"
+	"time"
+)
+
+type expiryKey struct {
+	tenant tenant.Model
+	id     uint32
+}
+
+// expiryHeap is a min-heap of invites ordered by deadline. index tracks each invite's position so it can be removed
+// when the invite is resolved before it expires.
+type expiryHeap struct {
+	entries []Model
+	index   map[expiryKey]int
+}
+
+func (h *expiryHeap) Len() int {
+	return len(h.entries)
+}
+
+func (h *expiryHeap) Less(i, j int) bool {
+	return h.entries[i].ExpiresAt().Before(h.entries[j].ExpiresAt())
+}
+
+func (h *expiryHeap) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+	h.index[expiryKey{h.entries[i].Tenant(), h.entries[i].Id()}] = i
+	h.index[expiryKey{h.entries[j].Tenant(), h.entries[j].Id()}] = j
+}
+
+func (h *expiryHeap) Push(x any) {
+	m := x.(Model)
+	h.index[expiryKey{m.Tenant(), m.Id()}] = len(h.entries)
+	h.entries = append(h.entries, m)
+}
+
+func (h *expiryHeap) Pop() any {
+	n := len(h.entries)
+	m := h.entries[n-1]
+	h.entries = h.entries[:n-1]
+	delete(h.index, expiryKey{m.Tenant(), m.Id()})
+	return m
+}
+
+// expiryIndex lets the registry find expired invites in time proportional to the number that are due, rather than
+// the number pending.
+type expiryIndex struct {
+	lock sync.Mutex
+	h    *expiryHeap
+}
+
+func newExpiryIndex() *expiryIndex {
+	return &expiryIndex{h: &expiryHeap{entries: make([]Model, 0), index: make(map[expiryKey]int)}}
+}
+
+func (e *expiryIndex) add(m Model) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	heap.Push(e.h, m)
+}
+
+func (e *expiryIndex) remove(t tenant.Model, id uint32) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if i, ok := e.h.index[expiryKey{t, id}]; ok {
+		heap.Remove(e.h, i)
+	}
+}
+
+// due returns every invite whose deadline is at or before now without removing it. Only the due prefix of the heap
+// is visited, as the children of a node that has not expired cannot have expired either.
+func (e *expiryIndex) due(now time.Time) []Model {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	results := make([]Model, 0)
+	pending := []int{0}
+	for len(pending) > 0 {
+		i := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if i >= len(e.h.entries) || e.h.entries[i].ExpiresAt().After(now) {
+			continue
+		}
+		results = append(results, e.h.entries[i])
+		pending = append(pending, 2*i+1, 2*i+2)
+	}
+	return results
+}
@@ -0,0 +1,163 @@
+package invite
+
+import (
+	"container/heap"
+	"fmt"
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
+	"testing"
+	"time"
+)
+
+func testTenant(t testing.TB) tenant.Model {
+	tm, err := tenant.Create(uuid.New(), "GMS", 83, 1)
+	if err != nil {
+		t.Fatalf("Unable to create tenant: %v", err)
+	}
+	return tm
+}
+
+func TestExpiryIndexOrdersByDeadline(t *testing.T) {
+	tm := testTenant(t)
+	now := time.Now()
+	offsets := []time.Duration{5, -3, 0, 8, -1, 2, -7, 4}
+
+	e := newExpiryIndex()
+	for i, o := range offsets {
+		e.add(Model{tenant: tm, id: uint32(i + 1), expiresAt: now.Add(o * time.Second)})
+	}
+
+	due := e.due(now)
+	if len(due) != 4 {
+		t.Fatalf("Expected 4 due invites, got %d.", len(due))
+	}
+	for _, m := range due {
+		if m.ExpiresAt().After(now) {
+			t.Errorf("Invite [%d] is not due until %s.", m.Id(), m.ExpiresAt())
+		}
+	}
+
+	var last time.Time
+	for e.h.Len() > 0 {
+		m := heap.Pop(e.h).(Model)
+		if m.ExpiresAt().Before(last) {
+			t.Fatalf("Invite [%d] popped out of deadline order.", m.Id())
+		}
+		last = m.ExpiresAt()
+	}
+	if len(e.h.index) != 0 {
+		t.Errorf("Expected index to be empty, holds %d entries.", len(e.h.index))
+	}
+}
+
+func TestExpiryIndexRemove(t *testing.T) {
+	tm := testTenant(t)
+	now := time.Now()
+
+	e := newExpiryIndex()
+	for i := 1; i <= 10; i++ {
+		e.add(Model{tenant: tm, id: uint32(i), expiresAt: now.Add(time.Duration(i%4-2) * time.Second)})
+	}
+	e.remove(tm, 4)
+	e.remove(tm, 8)
+	e.remove(tm, 42)
+	e.remove(testTenant(t), 1)
+
+	if e.h.Len() != 8 {
+		t.Fatalf("Expected 8 indexed invites, got %d.", e.h.Len())
+	}
+	for i, m := range e.h.entries {
+		if m.Id() == 4 || m.Id() == 8 {
+			t.Errorf("Invite [%d] remains indexed after removal.", m.Id())
+		}
+		if e.h.index[expiryKey{m.Tenant(), m.Id()}] != i {
+			t.Errorf("Invite [%d] is indexed at the wrong position.", m.Id())
+		}
+		if i > 0 && m.ExpiresAt().Before(e.h.entries[(i-1)/2].ExpiresAt()) {
+			t.Errorf("Invite [%d] expires before its parent.", m.Id())
+		}
+	}
+}
+
+func TestGetExpiredExcludesResolved(t *testing.T) {
+	tm := testTenant(t)
+	r := NewInMemoryRegistry()
+	xp := DefaultExclusivityPolicy()
+	cp := DefaultCapacityPolicy()
+
+	for _, targetId := range []uint32{2, 3, 4} {
+		_, _, err := r.Create(tm, 1, 0, targetId, "BUDDY", targetId, time.Now(), -time.Second, xp, cp)
+		if err != nil {
+			t.Fatalf("Unable to create invite: %v", err)
+		}
+	}
+	_, err := r.Resolve(tm, 3, "BUDDY", 1, StatusAccepted, time.Now())
+	if err != nil {
+		t.Fatalf("Unable to resolve invite: %v", err)
+	}
+
+	is, err := r.GetExpired()
+	if err != nil {
+		t.Fatalf("Unable to get expired invites: %v", err)
+	}
+	if len(is) != 2 {
+		t.Fatalf("Expected 2 expired invites, got %d.", len(is))
+	}
+	for _, i := range is {
+		if i.TargetId() == 3 {
+			t.Errorf("Resolved invite [%d] reported as expired.", i.Id())
+		}
+	}
+}
+
+// scanExpired is the full scan of every pending invite GetExpired performed before invites were indexed by deadline.
+func scanExpired(r *InMemoryRegistry) []Model {
+	var results = make([]Model, 0)
+	for k, v := range r.inviteReg {
+		if tl, ok := r.tenantLock[k]; ok {
+			tl.RLock()
+			for _, cir := range v {
+				for _, is := range cir {
+					for _, i := range is {
+						if i.Expired() {
+							results = append(results, i)
+						}
+					}
+				}
+			}
+			tl.RUnlock()
+		}
+	}
+	return results
+}
+
+// BenchmarkGetExpired compares the deadline index with a full scan when one in every thousand pending invites is due.
+func BenchmarkGetExpired(b *testing.B) {
+	for _, n := range []int{100_000, 1_000_000} {
+		tm := testTenant(b)
+		r := NewInMemoryRegistry()
+		xp := DefaultExclusivityPolicy()
+		cp := DefaultCapacityPolicy()
+		for i := 0; i < n; i++ {
+			ttl := time.Hour
+			if i%1000 == 0 {
+				ttl = -time.Second
+			}
+			_, _, err := r.Create(tm, uint32(n+i+1), 0, uint32(i+1), "BUDDY", uint32(i+1), time.Now(), ttl, xp, cp)
+			if err != nil {
+				b.Fatalf("Unable to create invite: %v", err)
+			}
+		}
+
+		b.Run(fmt.Sprintf("heap/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = r.GetExpired()
+			}
+		})
+		b.Run(fmt.Sprintf("scan/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = scanExpired(r)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package invite
 
 import (
 	"github.com/Chronicle20/atlas-tenant"
+	"slices"
 	"time"
 )
 
@@ -14,6 +15,9 @@ type Model struct {
 	targetId     uint32
 	worldId      byte
 	age          time.Time
+	expiresAt    time.Time
+	status       Status
+	history      []Transition
 }
 
 func (m Model) ReferenceId() uint32 {
@@ -28,11 +32,16 @@ func (m Model) OriginatorId() uint32 {
 	return m.originatorId
 }
 
-func (m Model) Expired(timeout time.Duration) bool {
-	if time.Now().Sub(m.Age()) > timeout {
-		return true
-	}
-	return false
+func (m Model) Expired() bool {
+	return !time.Now().Before(m.ExpiresAt())
+}
+
+func (m Model) ExpiresAt() time.Time {
+	return m.expiresAt
+}
+
+func (m Model) Ttl() time.Duration {
+	return m.expiresAt.Sub(m.age)
 }
 
 func (m Model) Age() time.Time {
@@ -54,3 +63,73 @@ func (m Model) Type() string {
 func (m Model) WorldId() byte {
 	return m.worldId
 }
+
+// Status returns the invite's current status. Invites are created PENDING and resolved by a single transition to one
+// of the other statuses.
+func (m Model) Status() Status {
+	return m.status
+}
+
+func (m Model) Pending() bool {
+	return m.status == StatusPending
+}
+
+// History returns the invite's transitions, oldest first.
+func (m Model) History() []Transition {
+	return m.history
+}
+
+// ResolvedAt returns when the invite left PENDING, or the zero time while it is pending.
+func (m Model) ResolvedAt() time.Time {
+	if m.Pending() || len(m.history) == 0 {
+		return time.Time{}
+	}
+	return m.history[len(m.history)-1].At()
+}
+
+// Transition returns a copy of the invite moved to status at, or ErrInvalidTransition when the invite's current status
+// does not allow it.
+func (m Model) Transition(status Status, at time.Time) (Model, error) {
+	if !m.status.CanTransition(status) {
+		return m, ErrInvalidTransition
+	}
+	history := make([]Transition, 0, len(m.history)+1)
+	history = append(history, m.history...)
+	m.history = append(history, Transition{status: status, at: at})
+	m.status = status
+	return m, nil
+}
+
+type Status string
+
+const (
+	StatusPending    Status = "PENDING"
+	StatusAccepted   Status = "ACCEPTED"
+	StatusRejected   Status = "REJECTED"
+	StatusCancelled  Status = "CANCELLED"
+	StatusExpired    Status = "EXPIRED"
+	StatusSuperseded Status = "SUPERSEDED"
+)
+
+var transitions = map[Status][]Status{
+	StatusPending: {StatusAccepted, StatusRejected, StatusCancelled, StatusExpired, StatusSuperseded},
+}
+
+// CanTransition reports whether an invite in status s may move to status to.
+func (s Status) CanTransition(to Status) bool {
+	return slices.Contains(transitions[s], to)
+}
+
+// Transition records an invite entering a status.
+type Transition struct {
+	status Status
+	at     time.Time
+}
+
+func (t Transition) Status() Status {
+	return t.status
+}
+
+func (t Transition) At() time.Time {
+	return t.at
+}
@@ -1,27 +1,53 @@
 package invite
 
 import (
+	"atlas-invites/audit"
+	"atlas-invites/block"
 	"atlas-invites/kafka/message"
 	invite2 "atlas-invites/kafka/message/invite"
 	"atlas-invites/kafka/producer"
+	"atlas-invites/metrics"
+	"atlas-invites/outbox"
+	"atlas-invites/preference"
 	"context"
+	"errors"
 	"github.com/Chronicle20/atlas-model/model"
 	"github.com/Chronicle20/atlas-tenant"
 	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
 	"github.com/sirupsen/logrus"
+	"time"
 )
 
 const StartInviteId = uint32(1000000000)
 
 type Processor interface {
+	GetById(id uint32) (Model, error)
+	ByIdProvider(id uint32) model.Provider[Model]
 	GetByCharacterId(characterId uint32) ([]Model, error)
 	ByCharacterIdProvider(characterId uint32) model.Provider[[]Model]
+	GetByOriginatorId(originatorId uint32) ([]Model, error)
+	ByOriginatorIdProvider(originatorId uint32) model.Provider[[]Model]
+	GetByReference(inviteType string, referenceId uint32) ([]Model, error)
+	ByReferenceProvider(inviteType string, referenceId uint32) model.Provider[[]Model]
+	GetCooldowns(originatorId uint32, targetId uint32) ([]Cooldown, error)
+	CooldownsProvider(originatorId uint32, targetId uint32) model.Provider[[]Cooldown]
+	Subscribe(characterId uint32) (<-chan Update, func())
 	CreateAndEmit(referenceId uint32, worldId byte, inviteType string, originatorId uint32, targetId uint32, transactionId uuid.UUID) (Model, error)
 	Create(mb *message.Buffer) func(referenceId uint32) func(worldId byte) func(inviteType string) func(originatorId uint32) func(targetId uint32) func(transactionId uuid.UUID) (Model, error)
 	AcceptAndEmit(referenceId uint32, worldId byte, inviteType string, actorId uint32, transactionId uuid.UUID) (Model, error)
 	Accept(mb *message.Buffer) func(referenceId uint32) func(worldId byte) func(inviteType string) func(actorId uint32) func(transactionId uuid.UUID) (Model, error)
 	RejectAndEmit(originatorId uint32, worldId byte, inviteType string, actorId uint32, transactionId uuid.UUID) (Model, error)
 	Reject(mb *message.Buffer) func(originatorId uint32) func(worldId byte) func(inviteType string) func(actorId uint32) func(transactionId uuid.UUID) (Model, error)
+	CancelAndEmit(referenceId uint32, worldId byte, inviteType string, actorId uint32, targetId uint32, transactionId uuid.UUID) (Model, error)
+	Cancel(mb *message.Buffer) func(referenceId uint32) func(worldId byte) func(inviteType string) func(actorId uint32) func(targetId uint32) func(transactionId uuid.UUID) (Model, error)
+	PurgeAndEmit(eventType string, characterId uint32, transactionId uuid.UUID) ([]Model, error)
+	Purge(mb *message.Buffer) func(eventType string) func(characterId uint32) func(transactionId uuid.UUID) ([]Model, error)
+	CancelByReferenceAndEmit(inviteType string, referenceId uint32, transactionId uuid.UUID) ([]Model, error)
+	CancelByReference(mb *message.Buffer) func(inviteType string) func(referenceId uint32) func(transactionId uuid.UUID) ([]Model, error)
+	EmitOnce(key string) func(fail func(p Processor, buf *message.Buffer, cause error) error) func(f func(p Processor, buf *message.Buffer) error) error
+	ErrorAndEmit(referenceId uint32, worldId byte, inviteType string, commandType string, originatorId uint32, targetId uint32, transactionId uuid.UUID, cause error) error
+	Error(mb *message.Buffer) func(referenceId uint32) func(worldId byte) func(inviteType string) func(commandType string) func(originatorId uint32) func(targetId uint32) func(transactionId uuid.UUID) func(cause error) error
 }
 
 type ProcessorImpl struct {
@@ -29,6 +55,20 @@ type ProcessorImpl struct {
 	ctx context.Context
 	t   tenant.Model
 	p   producer.Provider
+	r   Registry
+	rc  message.Recorder
+	ep  ExpirationPolicy
+	pp  PurgePolicy
+	rp  ReinvitePolicy
+	sp  SymmetryPolicy
+	xp  ExclusivityPolicy
+	cp  CapacityPolicy
+	rl  *RateLimiter
+	ap  audit.Processor
+	al  []audit.Model
+	b   *Broker
+	ul  []Update
+	ml  []func()
 }
 
 func NewProcessor(l logrus.FieldLogger, ctx context.Context) Processor {
@@ -36,22 +76,206 @@ func NewProcessor(l logrus.FieldLogger, ctx context.Context) Processor {
 		l:   l,
 		ctx: ctx,
 		t:   tenant.MustFromContext(ctx),
-		p:   producer.ProviderImpl(l)(ctx),
+		p:   outbox.ProviderImpl(outbox.GetStore())(ctx),
+		r:   GetRegistry(),
+		ep:  GetExpirationPolicy(),
+		pp:  GetPurgePolicy(),
+		rp:  GetReinvitePolicy(),
+		sp:  GetSymmetryPolicy(),
+		xp:  GetExclusivityPolicy(),
+		cp:  GetCapacityPolicy(),
+		rl:  GetRateLimiter(),
+		ap:  audit.NewProcessor(l, ctx),
+		b:   GetBroker(),
 	}
 }
 
+// emit runs f against a processor bound to a registry transaction. The messages f buffers are added to the outbox in
+// that transaction and published by the relay once it commits. The audit log entries f notes are recorded, the updates
+// it publishes delivered to subscribers, and the metrics it counts updated, once it commits.
+func (p *ProcessorImpl) emit(f func(p *ProcessorImpl, buf *message.Buffer) error) error {
+	var tp *ProcessorImpl
+	err := p.r.Transaction(func(r Registry, s outbox.Store, rc message.Recorder) error {
+		tp = p.with(r, s, rc)
+		return message.Emit(tp.p)(func(buf *message.Buffer) error {
+			return f(tp, buf)
+		})
+	})
+	if err != nil {
+		return err
+	}
+	outbox.Notify()
+	if len(tp.al) > 0 {
+		_ = p.ap.Record(tp.al...)
+	}
+	p.b.Publish(tp.ul...)
+	for _, m := range tp.ml {
+		m()
+	}
+	return nil
+}
+
+// note holds an audit log entry until the transaction the processor is bound to commits.
+func (p *ProcessorImpl) note(m audit.Model) {
+	p.al = append(p.al, m)
+}
+
+// publish holds an update to i until the transaction the processor is bound to commits.
+func (p *ProcessorImpl) publish(eventType string, i Model) {
+	p.ul = append(p.ul, Update{eventType: eventType, invite: i})
+}
+
+// statusEvent counts a status event of eventType once the transaction the processor is bound to commits.
+func (p *ProcessorImpl) statusEvent(inviteType string, eventType string) {
+	p.ml = append(p.ml, func() {
+		metrics.StatusEvent(p.t, inviteType, eventType)
+	})
+}
+
+// decision observes how long an invite created at age waited for a decision once the transaction the processor is
+// bound to commits.
+func (p *ProcessorImpl) decision(inviteType string, eventType string, age time.Time) {
+	p.ml = append(p.ml, func() {
+		metrics.Decision(p.t, inviteType, eventType, age)
+	})
+}
+
+func (p *ProcessorImpl) with(r Registry, s outbox.Store, rc message.Recorder) *ProcessorImpl {
+	return &ProcessorImpl{
+		l:   p.l,
+		ctx: p.ctx,
+		t:   p.t,
+		p:   outbox.ProviderImpl(s)(p.ctx),
+		r:   r,
+		rc:  rc,
+		ep:  p.ep,
+		pp:  p.pp,
+		rp:  p.rp,
+		sp:  p.sp,
+		xp:  p.xp,
+		cp:  p.cp,
+		rl:  p.rl,
+		ap:  p.ap,
+		b:   p.b,
+	}
+}
+
+// EmitOnce runs f against a processor bound to a registry transaction at most once per key. The buffered messages are
+// recorded under key in that transaction. When f fails its changes are rolled back, and the messages fail buffers in
+// reply to the cause are recorded under key in their place, so a redelivered command fails alike. When messages are
+// already recorded under key, neither is invoked and the recorded messages are added to the outbox again. The cause f
+// failed with is returned once fail has run.
+func (p *ProcessorImpl) EmitOnce(key string) func(fail func(p Processor, buf *message.Buffer, cause error) error) func(f func(p Processor, buf *message.Buffer) error) error {
+	return func(fail func(p Processor, buf *message.Buffer, cause error) error) func(f func(p Processor, buf *message.Buffer) error) error {
+		return func(f func(p Processor, buf *message.Buffer) error) error {
+			cause := p.emitOnce(key, f)
+			if cause == nil {
+				return nil
+			}
+			err := p.emitOnce(key, func(p Processor, buf *message.Buffer) error {
+				return fail(p, buf, cause)
+			})
+			if err != nil {
+				return err
+			}
+			return cause
+		}
+	}
+}
+
+// emitOnce runs f against a processor bound to a registry transaction, recording the messages it buffers under key in
+// that transaction. When messages are already recorded under key, f is not invoked and they are added to the outbox
+// again.
+func (p *ProcessorImpl) emitOnce(key string, f func(p Processor, buf *message.Buffer) error) error {
+	return p.emit(func(tp *ProcessorImpl, buf *message.Buffer) error {
+		ms, ok, err := tp.rc.Get(key)
+		if err != nil {
+			return err
+		}
+		if ok {
+			for t, tms := range ms {
+				err = buf.Put(t, model.FixedProvider(tms))
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		err = f(tp, buf)
+		if err != nil {
+			return err
+		}
+		return tp.rc.Put(key, buf.GetAll())
+	})
+}
+
+func (p *ProcessorImpl) GetById(id uint32) (Model, error) {
+	return p.ByIdProvider(id)()
+}
+
+func (p *ProcessorImpl) ByIdProvider(id uint32) model.Provider[Model] {
+	i, err := p.r.GetById(p.t, id)
+	if err != nil {
+		return model.ErrorProvider[Model](err)
+	}
+	return model.FixedProvider(i)
+}
+
 func (p *ProcessorImpl) GetByCharacterId(characterId uint32) ([]Model, error) {
 	return p.ByCharacterIdProvider(characterId)()
 }
 
 func (p *ProcessorImpl) ByCharacterIdProvider(characterId uint32) model.Provider[[]Model] {
-	is, err := GetRegistry().GetForCharacter(p.t, characterId)
+	is, err := p.r.GetForCharacter(p.t, characterId)
+	if err != nil {
+		return model.ErrorProvider[[]Model](err)
+	}
+	return model.FixedProvider(is)
+}
+
+func (p *ProcessorImpl) GetByOriginatorId(originatorId uint32) ([]Model, error) {
+	return p.ByOriginatorIdProvider(originatorId)()
+}
+
+func (p *ProcessorImpl) ByOriginatorIdProvider(originatorId uint32) model.Provider[[]Model] {
+	is, err := p.r.GetForOriginator(p.t, originatorId)
 	if err != nil {
 		return model.ErrorProvider[[]Model](err)
 	}
 	return model.FixedProvider(is)
 }
 
+func (p *ProcessorImpl) GetByReference(inviteType string, referenceId uint32) ([]Model, error) {
+	return p.ByReferenceProvider(inviteType, referenceId)()
+}
+
+func (p *ProcessorImpl) ByReferenceProvider(inviteType string, referenceId uint32) model.Provider[[]Model] {
+	is, err := p.r.GetForReference(p.t, inviteType, referenceId)
+	if err != nil {
+		return model.ErrorProvider[[]Model](err)
+	}
+	return model.FixedProvider(is)
+}
+
+func (p *ProcessorImpl) GetCooldowns(originatorId uint32, targetId uint32) ([]Cooldown, error) {
+	return p.CooldownsProvider(originatorId, targetId)()
+}
+
+func (p *ProcessorImpl) CooldownsProvider(originatorId uint32, targetId uint32) model.Provider[[]Cooldown] {
+	cs, err := p.r.GetCooldowns(p.t, originatorId, targetId)
+	if err != nil {
+		return model.ErrorProvider[[]Cooldown](err)
+	}
+	return model.FixedProvider(cs)
+}
+
+// Subscribe returns a channel receiving updates to the invites characterId receives, and a function ending the
+// subscription.
+func (p *ProcessorImpl) Subscribe(characterId uint32) (<-chan Update, func()) {
+	return p.b.Subscribe(p.t, characterId)
+}
+
 // Create implements the business logic for creating an invite
 func (p *ProcessorImpl) Create(mb *message.Buffer) func(referenceId uint32) func(worldId byte) func(inviteType string) func(originatorId uint32) func(targetId uint32) func(transactionId uuid.UUID) (Model, error) {
 	return func(referenceId uint32) func(worldId byte) func(inviteType string) func(originatorId uint32) func(targetId uint32) func(transactionId uuid.UUID) (Model, error) {
@@ -69,7 +293,113 @@ func (p *ProcessorImpl) Create(mb *message.Buffer) func(referenceId uint32) func
 								"transaction":  transactionId.String(),
 							}).Debug("Creating invite")
 
-							i := GetRegistry().Create(p.t, originatorId, worldId, targetId, inviteType, referenceId)
+							if originatorId == targetId {
+								p.l.WithFields(logrus.Fields{
+									"originatorId": originatorId,
+									"inviteType":   inviteType,
+									"transaction":  transactionId.String(),
+								}).Warn("Character attempted to invite themselves")
+								return Model{}, ErrSelfInvite
+							}
+							if !validType(inviteType) {
+								p.l.WithFields(logrus.Fields{
+									"inviteType":  inviteType,
+									"transaction": transactionId.String(),
+								}).Warn("Unable to create invite of unknown type")
+								return Model{}, ErrInvalidType
+							}
+							blocked, err := block.NewProcessor(p.l, p.ctx).IsBlocked(targetId, originatorId)
+							if err != nil {
+								return Model{}, err
+							}
+							if blocked {
+								p.l.WithFields(logrus.Fields{
+									"inviteType":   inviteType,
+									"originatorId": originatorId,
+									"targetId":     targetId,
+									"transaction":  transactionId.String(),
+								}).Info("Dropping invite from blocked originator")
+								return Model{}, ErrBlocked
+							}
+							declined, err := preference.NewProcessor(p.l, p.ctx).Declines(targetId, inviteType)
+							if err != nil {
+								return Model{}, err
+							}
+							if declined {
+								p.l.WithFields(logrus.Fields{
+									"inviteType":   inviteType,
+									"originatorId": originatorId,
+									"targetId":     targetId,
+									"transaction":  transactionId.String(),
+								}).Info("Target automatically declines invites of this type")
+								return Model{}, ErrPreferenceDeclined
+							}
+							if p.sp.Symmetric(inviteType) {
+								ri, ok, err := p.reciprocate(inviteType, originatorId, targetId)
+								if err != nil {
+									return Model{}, err
+								}
+								if ok {
+									return ri, p.reciprocated(mb)(ri)(transactionId)
+								}
+							}
+							cs, err := p.r.GetCooldowns(p.t, originatorId, targetId)
+							if err != nil {
+								return Model{}, err
+							}
+							for _, c := range cs {
+								if c.Type() == inviteType {
+									p.l.WithFields(logrus.Fields{
+										"inviteType":   inviteType,
+										"originatorId": originatorId,
+										"targetId":     targetId,
+										"expiresAt":    c.ExpiresAt(),
+										"transaction":  transactionId.String(),
+									}).Info("Originator must wait before inviting target again")
+									return Model{}, ErrCooldown
+								}
+							}
+							if scope, ok := p.rl.Allow(p.t, inviteType, originatorId, targetId); !ok {
+								p.l.WithFields(logrus.Fields{
+									"inviteType":   inviteType,
+									"originatorId": originatorId,
+									"targetId":     targetId,
+									"scope":        scope,
+									"transaction":  transactionId.String(),
+								}).Warn("Rate limiting invite creation")
+								metrics.RateLimited(p.t, inviteType, scope)
+								return Model{}, ErrRateLimited
+							}
+
+							i, es, err := p.r.Create(p.t, originatorId, worldId, targetId, inviteType, referenceId, time.Now(), p.ep.Ttl(p.t, inviteType), p.xp, p.cp)
+							if errors.Is(err, ErrExclusivityConflict) {
+								p.l.WithFields(logrus.Fields{
+									"inviteType":   inviteType,
+									"originatorId": originatorId,
+									"targetId":     targetId,
+									"transaction":  transactionId.String(),
+								}).Info("Character already has an outstanding invite of this type")
+								return Model{}, err
+							}
+							if errors.Is(err, ErrCapacityExceeded) {
+								p.l.WithFields(logrus.Fields{
+									"inviteType":   inviteType,
+									"originatorId": originatorId,
+									"targetId":     targetId,
+									"transaction":  transactionId.String(),
+								}).Warn("Pending invite limit reached")
+								return Model{}, err
+							}
+							if err != nil {
+								p.l.WithError(err).WithFields(logrus.Fields{
+									"referenceId":  referenceId,
+									"inviteType":   inviteType,
+									"originatorId": originatorId,
+									"targetId":     targetId,
+									"transaction":  transactionId.String(),
+								}).Error("Unable to create invite")
+								return Model{}, err
+							}
 
 							p.l.WithFields(logrus.Fields{
 								"inviteId":     i.Id(),
@@ -81,15 +411,23 @@ func (p *ProcessorImpl) Create(mb *message.Buffer) func(referenceId uint32) func
 								"transaction":  transactionId.String(),
 							}).Info("Invite created successfully")
 
-							err := mb.Put(invite2.EnvEventStatusTopic, createdStatusEventProvider(i.ReferenceId(), worldId, inviteType, i.OriginatorId(), i.TargetId(), transactionId))
+							err = p.evicted(mb)(es)(transactionId)
+							if err != nil {
+								return Model{}, err
+							}
+
+							err = mb.Put(invite2.EnvEventStatusTopic, createdStatusEventProvider(i.Id(), i.ReferenceId(), worldId, inviteType, i.OriginatorId(), i.TargetId(), transactionId))
 							if err != nil {
 								p.l.WithError(err).WithFields(logrus.Fields{
-									"inviteId":     i.Id(),
-									"referenceId":  i.ReferenceId(),
-									"transaction":  transactionId.String(),
+									"inviteId":    i.Id(),
+									"referenceId": i.ReferenceId(),
+									"transaction": transactionId.String(),
 								}).Error("Failed to put created event in message buffer")
 								return Model{}, err
 							}
+							p.statusEvent(inviteType, invite2.EventInviteStatusTypeCreated)
+							p.note(p.ap.Transition(string(StatusPending), worldId, inviteType, i.ReferenceId(), i.OriginatorId(), i.TargetId(), originatorId, "", transactionId))
+							p.publish(invite2.EventInviteStatusTypeCreated, i)
 							return i, nil
 						}
 					}
@@ -99,10 +437,99 @@ func (p *ProcessorImpl) Create(mb *message.Buffer) func(referenceId uint32) func
 	}
 }
 
+// reciprocate accepts the pending invite of inviteType from targetId to originatorId, returning it and true when one
+// was found.
+func (p *ProcessorImpl) reciprocate(inviteType string, originatorId uint32, targetId uint32) (Model, bool, error) {
+	ri, err := p.r.Resolve(p.t, originatorId, inviteType, targetId, StatusAccepted, time.Now())
+	if errors.Is(err, ErrNotFound) {
+		return Model{}, false, nil
+	}
+	if err != nil {
+		return Model{}, false, err
+	}
+	return ri, true, nil
+}
+
+// reciprocated buffers the ACCEPTED status event for ri, a pending invite accepted because its target sent its
+// originator an invite of the same symmetric type.
+func (p *ProcessorImpl) reciprocated(mb *message.Buffer) func(ri Model) func(transactionId uuid.UUID) error {
+	return func(ri Model) func(transactionId uuid.UUID) error {
+		return func(transactionId uuid.UUID) error {
+			p.l.WithFields(logrus.Fields{
+				"inviteId":     ri.Id(),
+				"referenceId":  ri.ReferenceId(),
+				"inviteType":   ri.Type(),
+				"originatorId": ri.OriginatorId(),
+				"targetId":     ri.TargetId(),
+				"transaction":  transactionId.String(),
+			}).Info("Reciprocal invite accepted")
+
+			err := mb.Put(invite2.EnvEventStatusTopic, acceptedStatusEventProvider(ri.Id(), ri.ReferenceId(), ri.WorldId(), ri.Type(), ri.OriginatorId(), ri.TargetId(), transactionId))
+			if err != nil {
+				p.l.WithError(err).WithFields(logrus.Fields{
+					"inviteId":    ri.Id(),
+					"referenceId": ri.ReferenceId(),
+					"transaction": transactionId.String(),
+				}).Error("Failed to put accepted event in message buffer")
+				return err
+			}
+			p.statusEvent(ri.Type(), invite2.EventInviteStatusTypeAccepted)
+			p.decision(ri.Type(), invite2.EventInviteStatusTypeAccepted, ri.Age())
+			p.note(p.ap.Transition(string(StatusAccepted), ri.WorldId(), ri.Type(), ri.ReferenceId(), ri.OriginatorId(), ri.TargetId(), ri.TargetId(), "", transactionId))
+			p.publish(invite2.EventInviteStatusTypeAccepted, ri)
+			return nil
+		}
+	}
+}
+
+// evicted buffers the status events for invites evicted to make room for a new one. Invites evicted as the oldest are
+// reported as EXPIRED, while those replaced or superseded by the new invite are reported as CANCELLED.
+func (p *ProcessorImpl) evicted(mb *message.Buffer) func(es []Eviction) func(transactionId uuid.UUID) error {
+	return func(es []Eviction) func(transactionId uuid.UUID) error {
+		return func(transactionId uuid.UUID) error {
+			for _, e := range es {
+				i := e.Invite()
+				p.l.WithFields(logrus.Fields{
+					"inviteId":     i.Id(),
+					"inviteType":   i.Type(),
+					"originatorId": i.OriginatorId(),
+					"targetId":     i.TargetId(),
+					"cause":        e.Cause(),
+					"transaction":  transactionId.String(),
+				}).Info("Evicted invite to make room for a new one")
+
+				eventType := invite2.EventInviteStatusTypeCancelled
+				var mp model.Provider[[]kafka.Message]
+				switch e.Cause() {
+				case EvictionOldest:
+					eventType = invite2.EventInviteStatusTypeExpired
+					mp = expiredStatusEventProvider(i.Id(), i.ReferenceId(), i.WorldId(), i.Type(), i.OriginatorId(), i.TargetId(), i.Age(), i.Ttl(), transactionId)
+				case EvictionReplaced:
+					mp = cancelledStatusEventProvider(i.Id(), i.ReferenceId(), i.WorldId(), i.Type(), i.OriginatorId(), i.TargetId(), invite2.CancelReasonReplaced, transactionId)
+				default:
+					mp = cancelledStatusEventProvider(i.Id(), i.ReferenceId(), i.WorldId(), i.Type(), i.OriginatorId(), i.TargetId(), invite2.CancelReasonSuperseded, transactionId)
+				}
+				err := mb.Put(invite2.EnvEventStatusTopic, mp)
+				if err != nil {
+					p.l.WithError(err).WithFields(logrus.Fields{
+						"inviteId":    i.Id(),
+						"transaction": transactionId.String(),
+					}).Error("Failed to put eviction event in message buffer")
+					return err
+				}
+				p.statusEvent(i.Type(), eventType)
+				p.note(p.ap.Transition(string(e.Cause().Status()), i.WorldId(), i.Type(), i.ReferenceId(), i.OriginatorId(), i.TargetId(), 0, string(e.Cause()), transactionId))
+				p.publish(eventType, i)
+			}
+			return nil
+		}
+	}
+}
+
 // CreateAndEmit implements the business logic for creating an invite and emitting the event
 func (p *ProcessorImpl) CreateAndEmit(referenceId uint32, worldId byte, inviteType string, originatorId uint32, targetId uint32, transactionId uuid.UUID) (Model, error) {
 	var m Model
-	err := message.Emit(p.p)(func(buf *message.Buffer) error {
+	err := p.emit(func(p *ProcessorImpl, buf *message.Buffer) error {
 		var err error
 		m, err = p.Create(buf)(referenceId)(worldId)(inviteType)(originatorId)(targetId)(transactionId)
 		return err
@@ -125,7 +552,7 @@ func (p *ProcessorImpl) Accept(mb *message.Buffer) func(referenceId uint32) func
 							"transaction": transactionId.String(),
 						}).Debug("Accepting invite")
 
-						i, err := GetRegistry().GetByReference(p.t, actorId, inviteType, referenceId)
+						i, err := p.r.GetByReference(p.t, actorId, inviteType, referenceId)
 						if err != nil {
 							p.l.WithError(err).WithFields(logrus.Fields{
 								"referenceId": referenceId,
@@ -145,7 +572,7 @@ func (p *ProcessorImpl) Accept(mb *message.Buffer) func(referenceId uint32) func
 							"transaction":  transactionId.String(),
 						}).Debug("Found invite to accept")
 
-						err = GetRegistry().Delete(p.t, actorId, inviteType, i.OriginatorId())
+						ai, err := p.r.Resolve(p.t, actorId, inviteType, i.OriginatorId(), StatusAccepted, time.Now())
 						if err != nil {
 							p.l.WithError(err).WithFields(logrus.Fields{
 								"inviteId":     i.Id(),
@@ -167,15 +594,19 @@ func (p *ProcessorImpl) Accept(mb *message.Buffer) func(referenceId uint32) func
 							"transaction":  transactionId.String(),
 						}).Info("Invite accepted successfully")
 
-						err = mb.Put(invite2.EnvEventStatusTopic, acceptedStatusEventProvider(i.ReferenceId(), worldId, inviteType, i.OriginatorId(), i.TargetId(), transactionId))
+						err = mb.Put(invite2.EnvEventStatusTopic, acceptedStatusEventProvider(i.Id(), i.ReferenceId(), worldId, inviteType, i.OriginatorId(), i.TargetId(), transactionId))
 						if err != nil {
 							p.l.WithError(err).WithFields(logrus.Fields{
-								"inviteId":     i.Id(),
-								"referenceId":  i.ReferenceId(),
-								"transaction":  transactionId.String(),
+								"inviteId":    i.Id(),
+								"referenceId": i.ReferenceId(),
+								"transaction": transactionId.String(),
 							}).Error("Failed to put accepted event in message buffer")
 							return Model{}, err
 						}
+						p.statusEvent(inviteType, invite2.EventInviteStatusTypeAccepted)
+						p.decision(inviteType, invite2.EventInviteStatusTypeAccepted, i.Age())
+						p.note(p.ap.Transition(string(StatusAccepted), worldId, inviteType, i.ReferenceId(), i.OriginatorId(), i.TargetId(), actorId, "", transactionId))
+						p.publish(invite2.EventInviteStatusTypeAccepted, ai)
 						return i, nil
 					}
 				}
@@ -187,7 +618,7 @@ func (p *ProcessorImpl) Accept(mb *message.Buffer) func(referenceId uint32) func
 // AcceptAndEmit implements the business logic for accepting an invite and emitting the event
 func (p *ProcessorImpl) AcceptAndEmit(referenceId uint32, worldId byte, inviteType string, actorId uint32, transactionId uuid.UUID) (Model, error) {
 	var m Model
-	err := message.Emit(p.p)(func(buf *message.Buffer) error {
+	err := p.emit(func(p *ProcessorImpl, buf *message.Buffer) error {
 		var err error
 		m, err = p.Accept(buf)(referenceId)(worldId)(inviteType)(actorId)(transactionId)
 		return err
@@ -210,7 +641,7 @@ func (p *ProcessorImpl) Reject(mb *message.Buffer) func(originatorId uint32) fun
 							"transaction":  transactionId.String(),
 						}).Debug("Rejecting invite")
 
-						i, err := GetRegistry().GetByOriginator(p.t, actorId, inviteType, originatorId)
+						i, err := p.r.GetByOriginator(p.t, actorId, inviteType, originatorId)
 						if err != nil {
 							p.l.WithError(err).WithFields(logrus.Fields{
 								"originatorId": originatorId,
@@ -230,7 +661,7 @@ func (p *ProcessorImpl) Reject(mb *message.Buffer) func(originatorId uint32) fun
 							"transaction":  transactionId.String(),
 						}).Debug("Found invite to reject")
 
-						err = GetRegistry().Delete(p.t, actorId, inviteType, originatorId)
+						ri, err := p.r.Resolve(p.t, actorId, inviteType, originatorId, StatusRejected, time.Now())
 						if err != nil {
 							p.l.WithError(err).WithFields(logrus.Fields{
 								"inviteId":     i.Id(),
@@ -243,6 +674,20 @@ func (p *ProcessorImpl) Reject(mb *message.Buffer) func(originatorId uint32) fun
 							return Model{}, err
 						}
 
+						if d := p.rp.Cooldown(inviteType); d > 0 {
+							err = p.r.AddCooldown(p.t, i.OriginatorId(), i.TargetId(), inviteType, time.Now().Add(d))
+							if err != nil {
+								p.l.WithError(err).WithFields(logrus.Fields{
+									"inviteId":     i.Id(),
+									"inviteType":   i.Type(),
+									"originatorId": i.OriginatorId(),
+									"targetId":     i.TargetId(),
+									"transaction":  transactionId.String(),
+								}).Error("Unable to record re-invite cooldown")
+								return Model{}, err
+							}
+						}
+
 						p.l.WithFields(logrus.Fields{
 							"inviteId":     i.Id(),
 							"referenceId":  i.ReferenceId(),
@@ -252,15 +697,19 @@ func (p *ProcessorImpl) Reject(mb *message.Buffer) func(originatorId uint32) fun
 							"transaction":  transactionId.String(),
 						}).Info("Invite rejected successfully")
 
-						err = mb.Put(invite2.EnvEventStatusTopic, rejectedStatusEventProvider(i.ReferenceId(), worldId, inviteType, i.OriginatorId(), i.TargetId(), transactionId))
+						err = mb.Put(invite2.EnvEventStatusTopic, rejectedStatusEventProvider(i.Id(), i.ReferenceId(), worldId, inviteType, i.OriginatorId(), i.TargetId(), invite2.RejectReasonRequested, transactionId))
 						if err != nil {
 							p.l.WithError(err).WithFields(logrus.Fields{
-								"inviteId":     i.Id(),
-								"referenceId":  i.ReferenceId(),
-								"transaction":  transactionId.String(),
+								"inviteId":    i.Id(),
+								"referenceId": i.ReferenceId(),
+								"transaction": transactionId.String(),
 							}).Error("Failed to put rejected event in message buffer")
 							return Model{}, err
 						}
+						p.statusEvent(inviteType, invite2.EventInviteStatusTypeRejected)
+						p.decision(inviteType, invite2.EventInviteStatusTypeRejected, i.Age())
+						p.note(p.ap.Transition(string(StatusRejected), worldId, inviteType, i.ReferenceId(), i.OriginatorId(), i.TargetId(), actorId, invite2.RejectReasonRequested, transactionId))
+						p.publish(invite2.EventInviteStatusTypeRejected, ri)
 						return i, nil
 					}
 				}
@@ -272,10 +721,312 @@ func (p *ProcessorImpl) Reject(mb *message.Buffer) func(originatorId uint32) fun
 // RejectAndEmit implements the business logic for rejecting an invite and emitting the event
 func (p *ProcessorImpl) RejectAndEmit(originatorId uint32, worldId byte, inviteType string, actorId uint32, transactionId uuid.UUID) (Model, error) {
 	var m Model
-	err := message.Emit(p.p)(func(buf *message.Buffer) error {
+	err := p.emit(func(p *ProcessorImpl, buf *message.Buffer) error {
 		var err error
 		m, err = p.Reject(buf)(originatorId)(worldId)(inviteType)(actorId)(transactionId)
 		return err
 	})
 	return m, err
 }
+
+// Cancel implements the business logic for an originator rescinding an invite they sent
+func (p *ProcessorImpl) Cancel(mb *message.Buffer) func(referenceId uint32) func(worldId byte) func(inviteType string) func(actorId uint32) func(targetId uint32) func(transactionId uuid.UUID) (Model, error) {
+	return func(referenceId uint32) func(worldId byte) func(inviteType string) func(actorId uint32) func(targetId uint32) func(transactionId uuid.UUID) (Model, error) {
+		return func(worldId byte) func(inviteType string) func(actorId uint32) func(targetId uint32) func(transactionId uuid.UUID) (Model, error) {
+			return func(inviteType string) func(actorId uint32) func(targetId uint32) func(transactionId uuid.UUID) (Model, error) {
+				return func(actorId uint32) func(targetId uint32) func(transactionId uuid.UUID) (Model, error) {
+					return func(targetId uint32) func(transactionId uuid.UUID) (Model, error) {
+						return func(transactionId uuid.UUID) (Model, error) {
+							p.l.WithFields(logrus.Fields{
+								"referenceId": referenceId,
+								"worldId":     worldId,
+								"inviteType":  inviteType,
+								"actorId":     actorId,
+								"targetId":    targetId,
+								"transaction": transactionId.String(),
+							}).Debug("Cancelling invite")
+
+							i, err := p.r.GetByReference(p.t, targetId, inviteType, referenceId)
+							if err == nil && i.OriginatorId() != actorId {
+								err = ErrNotFound
+							}
+							if err != nil {
+								p.l.WithError(err).WithFields(logrus.Fields{
+									"referenceId": referenceId,
+									"inviteType":  inviteType,
+									"actorId":     actorId,
+									"targetId":    targetId,
+									"transaction": transactionId.String(),
+								}).Error("Unable to locate invite being acted upon")
+								return Model{}, err
+							}
+
+							p.l.WithFields(logrus.Fields{
+								"inviteId":     i.Id(),
+								"referenceId":  i.ReferenceId(),
+								"inviteType":   i.Type(),
+								"originatorId": i.OriginatorId(),
+								"targetId":     i.TargetId(),
+								"transaction":  transactionId.String(),
+							}).Debug("Found invite to cancel")
+
+							ci, err := p.r.Resolve(p.t, targetId, inviteType, actorId, StatusCancelled, time.Now())
+							if err != nil {
+								p.l.WithError(err).WithFields(logrus.Fields{
+									"inviteId":     i.Id(),
+									"referenceId":  i.ReferenceId(),
+									"inviteType":   i.Type(),
+									"originatorId": i.OriginatorId(),
+									"targetId":     i.TargetId(),
+									"transaction":  transactionId.String(),
+								}).Error("Unable to delete invite being cancelled")
+								return Model{}, err
+							}
+
+							p.l.WithFields(logrus.Fields{
+								"inviteId":     i.Id(),
+								"referenceId":  i.ReferenceId(),
+								"inviteType":   i.Type(),
+								"originatorId": i.OriginatorId(),
+								"targetId":     i.TargetId(),
+								"transaction":  transactionId.String(),
+							}).Info("Invite cancelled successfully")
+
+							err = mb.Put(invite2.EnvEventStatusTopic, cancelledStatusEventProvider(i.Id(), i.ReferenceId(), worldId, inviteType, i.OriginatorId(), i.TargetId(), invite2.CancelReasonRequested, transactionId))
+							if err != nil {
+								p.l.WithError(err).WithFields(logrus.Fields{
+									"inviteId":    i.Id(),
+									"referenceId": i.ReferenceId(),
+									"transaction": transactionId.String(),
+								}).Error("Failed to put cancelled event in message buffer")
+								return Model{}, err
+							}
+							p.statusEvent(inviteType, invite2.EventInviteStatusTypeCancelled)
+							p.note(p.ap.Transition(string(StatusCancelled), worldId, inviteType, i.ReferenceId(), i.OriginatorId(), i.TargetId(), actorId, invite2.CancelReasonRequested, transactionId))
+							p.publish(invite2.EventInviteStatusTypeCancelled, ci)
+							return i, nil
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// CancelAndEmit implements the business logic for cancelling an invite and emitting the event
+func (p *ProcessorImpl) CancelAndEmit(referenceId uint32, worldId byte, inviteType string, actorId uint32, targetId uint32, transactionId uuid.UUID) (Model, error) {
+	var m Model
+	err := p.emit(func(p *ProcessorImpl, buf *message.Buffer) error {
+		var err error
+		m, err = p.Cancel(buf)(referenceId)(worldId)(inviteType)(actorId)(targetId)(transactionId)
+		return err
+	})
+	return m, err
+}
+
+// Purge implements the business logic for dropping the invites a character sent or received once the character becomes
+// unavailable. Only invite types the PurgePolicy associates with eventType are dropped.
+func (p *ProcessorImpl) Purge(mb *message.Buffer) func(eventType string) func(characterId uint32) func(transactionId uuid.UUID) ([]Model, error) {
+	return func(eventType string) func(characterId uint32) func(transactionId uuid.UUID) ([]Model, error) {
+		return func(characterId uint32) func(transactionId uuid.UUID) ([]Model, error) {
+			return func(transactionId uuid.UUID) ([]Model, error) {
+				p.l.WithFields(logrus.Fields{
+					"eventType":   eventType,
+					"characterId": characterId,
+					"transaction": transactionId.String(),
+				}).Debug("Purging invites for unavailable character")
+
+				received, err := p.r.GetForCharacter(p.t, characterId)
+				if err != nil {
+					return nil, err
+				}
+				sent, err := p.r.GetForOriginator(p.t, characterId)
+				if err != nil {
+					return nil, err
+				}
+
+				var is []Model
+				for _, i := range append(received, sent...) {
+					if p.pp.Purges(eventType, i.Type()) {
+						is = append(is, i)
+					}
+				}
+				return p.drop(mb)(is)(invite2.CancelReasonCharacterUnavailable)(transactionId)
+			}
+		}
+	}
+}
+
+// PurgeAndEmit implements the business logic for purging a character's invites and emitting the events
+func (p *ProcessorImpl) PurgeAndEmit(eventType string, characterId uint32, transactionId uuid.UUID) ([]Model, error) {
+	var ms []Model
+	err := p.emit(func(p *ProcessorImpl, buf *message.Buffer) error {
+		var err error
+		ms, err = p.Purge(buf)(eventType)(characterId)(transactionId)
+		return err
+	})
+	return ms, err
+}
+
+// CancelByReference implements the business logic for dropping every invite to a party, guild, alliance or messenger
+// room which no longer exists.
+func (p *ProcessorImpl) CancelByReference(mb *message.Buffer) func(inviteType string) func(referenceId uint32) func(transactionId uuid.UUID) ([]Model, error) {
+	return func(inviteType string) func(referenceId uint32) func(transactionId uuid.UUID) ([]Model, error) {
+		return func(referenceId uint32) func(transactionId uuid.UUID) ([]Model, error) {
+			return func(transactionId uuid.UUID) ([]Model, error) {
+				p.l.WithFields(logrus.Fields{
+					"inviteType":  inviteType,
+					"referenceId": referenceId,
+					"transaction": transactionId.String(),
+				}).Debug("Cancelling invites for disbanded reference")
+
+				is, err := p.r.GetForReference(p.t, inviteType, referenceId)
+				if err != nil {
+					return nil, err
+				}
+				return p.drop(mb)(is)(invite2.CancelReasonReferenceDisbanded)(transactionId)
+			}
+		}
+	}
+}
+
+// CancelByReferenceAndEmit implements the business logic for cancelling a reference's invites and emitting the events
+func (p *ProcessorImpl) CancelByReferenceAndEmit(inviteType string, referenceId uint32, transactionId uuid.UUID) ([]Model, error) {
+	var ms []Model
+	err := p.emit(func(p *ProcessorImpl, buf *message.Buffer) error {
+		var err error
+		ms, err = p.CancelByReference(buf)(inviteType)(referenceId)(transactionId)
+		return err
+	})
+	return ms, err
+}
+
+// drop removes invites on behalf of the service rather than a character, emitting a CANCELLED event carrying reason for
+// each. Invites removed concurrently by another actor are skipped.
+func (p *ProcessorImpl) drop(mb *message.Buffer) func(is []Model) func(reason string) func(transactionId uuid.UUID) ([]Model, error) {
+	return func(is []Model) func(reason string) func(transactionId uuid.UUID) ([]Model, error) {
+		return func(reason string) func(transactionId uuid.UUID) ([]Model, error) {
+			return func(transactionId uuid.UUID) ([]Model, error) {
+				results := make([]Model, 0)
+				for _, i := range is {
+					ci, err := p.r.Resolve(p.t, i.TargetId(), i.Type(), i.OriginatorId(), StatusCancelled, time.Now())
+					if errors.Is(err, ErrNotFound) {
+						continue
+					}
+					if err != nil {
+						p.l.WithError(err).WithFields(logrus.Fields{
+							"inviteId":     i.Id(),
+							"inviteType":   i.Type(),
+							"originatorId": i.OriginatorId(),
+							"targetId":     i.TargetId(),
+							"transaction":  transactionId.String(),
+						}).Error("Unable to delete invite being dropped")
+						return nil, err
+					}
+
+					p.l.WithFields(logrus.Fields{
+						"inviteId":     i.Id(),
+						"referenceId":  i.ReferenceId(),
+						"inviteType":   i.Type(),
+						"originatorId": i.OriginatorId(),
+						"targetId":     i.TargetId(),
+						"reason":       reason,
+						"transaction":  transactionId.String(),
+					}).Info("Invite dropped")
+
+					err = mb.Put(invite2.EnvEventStatusTopic, cancelledStatusEventProvider(i.Id(), i.ReferenceId(), i.WorldId(), i.Type(), i.OriginatorId(), i.TargetId(), reason, transactionId))
+					if err != nil {
+						p.l.WithError(err).WithFields(logrus.Fields{
+							"inviteId":    i.Id(),
+							"referenceId": i.ReferenceId(),
+							"transaction": transactionId.String(),
+						}).Error("Failed to put cancelled event in message buffer")
+						return nil, err
+					}
+					p.statusEvent(i.Type(), invite2.EventInviteStatusTypeCancelled)
+					p.note(p.ap.Transition(string(StatusCancelled), i.WorldId(), i.Type(), i.ReferenceId(), i.OriginatorId(), i.TargetId(), 0, reason, transactionId))
+					p.publish(invite2.EventInviteStatusTypeCancelled, ci)
+					results = append(results, i)
+				}
+				return results, nil
+			}
+		}
+	}
+}
+
+// Error implements the business logic for reporting a command which could not be processed
+func (p *ProcessorImpl) Error(mb *message.Buffer) func(referenceId uint32) func(worldId byte) func(inviteType string) func(commandType string) func(originatorId uint32) func(targetId uint32) func(transactionId uuid.UUID) func(cause error) error {
+	return func(referenceId uint32) func(worldId byte) func(inviteType string) func(commandType string) func(originatorId uint32) func(targetId uint32) func(transactionId uuid.UUID) func(cause error) error {
+		return func(worldId byte) func(inviteType string) func(commandType string) func(originatorId uint32) func(targetId uint32) func(transactionId uuid.UUID) func(cause error) error {
+			return func(inviteType string) func(commandType string) func(originatorId uint32) func(targetId uint32) func(transactionId uuid.UUID) func(cause error) error {
+				return func(commandType string) func(originatorId uint32) func(targetId uint32) func(transactionId uuid.UUID) func(cause error) error {
+					return func(originatorId uint32) func(targetId uint32) func(transactionId uuid.UUID) func(cause error) error {
+						return func(targetId uint32) func(transactionId uuid.UUID) func(cause error) error {
+							return func(transactionId uuid.UUID) func(cause error) error {
+								return func(cause error) error {
+									if errors.Is(cause, ErrPreferenceDeclined) {
+										return p.declined(mb, referenceId, worldId, inviteType, originatorId, targetId, transactionId)
+									}
+
+									reason := Reason(cause)
+									p.l.WithError(cause).WithFields(logrus.Fields{
+										"referenceId":  referenceId,
+										"inviteType":   inviteType,
+										"commandType":  commandType,
+										"reason":       reason,
+										"originatorId": originatorId,
+										"targetId":     targetId,
+										"transaction":  transactionId.String(),
+									}).Debug("Reporting failed invite command")
+
+									err := mb.Put(invite2.EnvEventStatusTopic, errorStatusEventProvider(referenceId, worldId, inviteType, commandType, reason, originatorId, targetId, transactionId))
+									if err != nil {
+										p.l.WithError(err).WithFields(logrus.Fields{
+											"referenceId": referenceId,
+											"transaction": transactionId.String(),
+										}).Error("Failed to put error event in message buffer")
+										return err
+									}
+									p.statusEvent(inviteType, invite2.EventInviteStatusTypeError)
+									p.note(p.ap.Refusal(commandType, worldId, inviteType, referenceId, originatorId, targetId, CommandActor(commandType, originatorId, targetId), reason, transactionId))
+									return nil
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// declined answers an invite the target automatically declines with a REJECTED event, as if the target had rejected it.
+func (p *ProcessorImpl) declined(mb *message.Buffer, referenceId uint32, worldId byte, inviteType string, originatorId uint32, targetId uint32, transactionId uuid.UUID) error {
+	err := mb.Put(invite2.EnvEventStatusTopic, rejectedStatusEventProvider(0, referenceId, worldId, inviteType, originatorId, targetId, invite2.RejectReasonPreferenceDeclined, transactionId))
+	if err != nil {
+		p.l.WithError(err).WithFields(logrus.Fields{
+			"referenceId": referenceId,
+			"transaction": transactionId.String(),
+		}).Error("Failed to put rejected event in message buffer")
+		return err
+	}
+	p.statusEvent(inviteType, invite2.EventInviteStatusTypeRejected)
+	p.note(p.ap.Transition(string(StatusRejected), worldId, inviteType, referenceId, originatorId, targetId, targetId, invite2.RejectReasonPreferenceDeclined, transactionId))
+	return nil
+}
+
+// ErrorAndEmit implements the business logic for reporting a failed command and emitting the event
+func (p *ProcessorImpl) ErrorAndEmit(referenceId uint32, worldId byte, inviteType string, commandType string, originatorId uint32, targetId uint32, transactionId uuid.UUID, cause error) error {
+	return p.emit(func(p *ProcessorImpl, buf *message.Buffer) error {
+		return p.Error(buf)(referenceId)(worldId)(inviteType)(commandType)(originatorId)(targetId)(transactionId)(cause)
+	})
+}
+
+// CommandActor returns the character which issued a command of commandType. Accepting or rejecting an invite is done by
+// its target, while creating or cancelling one is done by its originator.
+func CommandActor(commandType string, originatorId uint32, targetId uint32) uint32 {
+	if commandType == invite2.CommandInviteTypeAccept || commandType == invite2.CommandInviteTypeReject {
+		return targetId
+	}
+	return originatorId
+}
@@ -0,0 +1,269 @@
+package invite
+
+import (
+	"atlas-invites/block"
+	"atlas-invites/kafka/message"
+	invite2 "atlas-invites/kafka/message/invite"
+	"atlas-invites/outbox"
+	"atlas-invites/preference"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"testing"
+)
+
+func testProcessor(t *testing.T) (logrus.FieldLogger, context.Context, Processor) {
+	l, _ := test.NewNullLogger()
+	ctx := tenant.WithContext(context.Background(), testTenant(t))
+	return l, ctx, NewProcessor(l, ctx)
+}
+
+func TestCreateReciprocalInviteAccepts(t *testing.T) {
+	_, _, p := testProcessor(t)
+
+	a, err := p.CreateAndEmit(2, 0, invite2.InviteTypeBuddy, 2, 1, uuid.New())
+	if err != nil {
+		t.Fatalf("Unable to create invite: %v", err)
+	}
+	m, err := p.CreateAndEmit(1, 0, invite2.InviteTypeBuddy, 1, 2, uuid.New())
+	if err != nil {
+		t.Fatalf("Unable to create reciprocal invite: %v", err)
+	}
+	if m.Id() != a.Id() || m.Status() != StatusAccepted {
+		t.Errorf("Expected invite [%d] to be accepted, got [%d] in [%s].", a.Id(), m.Id(), m.Status())
+	}
+	for _, characterId := range []uint32{1, 2} {
+		is, err := p.GetByCharacterId(characterId)
+		if err != nil {
+			t.Fatalf("Unable to get invites: %v", err)
+		}
+		if len(is) != 0 {
+			t.Errorf("Expected no pending invites for character [%d], got %d.", characterId, len(is))
+		}
+	}
+}
+
+func TestCreateReciprocalInviteChecksTargetFirst(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(l logrus.FieldLogger, ctx context.Context) error
+		err     error
+	}{
+		{"blocked", func(l logrus.FieldLogger, ctx context.Context) error {
+			_, err := block.NewProcessor(l, ctx).Add(2, 1)
+			return err
+		}, ErrBlocked},
+		{"declined", func(l logrus.FieldLogger, ctx context.Context) error {
+			_, err := preference.NewProcessor(l, ctx).Update(2, map[string]bool{invite2.InviteTypeBuddy: true})
+			return err
+		}, ErrPreferenceDeclined},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, ctx, p := testProcessor(t)
+
+			a, err := p.CreateAndEmit(2, 0, invite2.InviteTypeBuddy, 2, 1, uuid.New())
+			if err != nil {
+				t.Fatalf("Unable to create invite: %v", err)
+			}
+			err = tt.prepare(l, ctx)
+			if err != nil {
+				t.Fatalf("Unable to prepare target: %v", err)
+			}
+
+			_, err = p.CreateAndEmit(1, 0, invite2.InviteTypeBuddy, 1, 2, uuid.New())
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected [%v], got [%v].", tt.err, err)
+			}
+			m, err := p.GetById(a.Id())
+			if err != nil {
+				t.Fatalf("Unable to get invite: %v", err)
+			}
+			if !m.Pending() {
+				t.Errorf("Expected invite [%d] to remain pending, got [%s].", m.Id(), m.Status())
+			}
+		})
+	}
+}
+
+// statusEvents decodes the status events buffered in mb.
+func statusEvents[E any](t *testing.T, mb *message.Buffer) []invite2.StatusEvent[E] {
+	results := make([]invite2.StatusEvent[E], 0)
+	for _, m := range mb.GetAll()[invite2.EnvEventStatusTopic] {
+		var e invite2.StatusEvent[E]
+		err := json.Unmarshal(m.Value, &e)
+		if err != nil {
+			t.Fatalf("Unable to decode status event: %v", err)
+		}
+		results = append(results, e)
+	}
+	return results
+}
+
+func TestCreateFromBlockedOriginatorDropped(t *testing.T) {
+	l, ctx, p := testProcessor(t)
+	_, err := block.NewProcessor(l, ctx).Add(2, 1)
+	if err != nil {
+		t.Fatalf("Unable to block character: %v", err)
+	}
+
+	transactionId := uuid.New()
+	_, err = p.CreateAndEmit(1, 0, invite2.InviteTypeParty, 1, 2, transactionId)
+	if !errors.Is(err, ErrBlocked) {
+		t.Fatalf("Expected [%v], got [%v].", ErrBlocked, err)
+	}
+	is, err := p.GetByCharacterId(2)
+	if err != nil {
+		t.Fatalf("Unable to get invites: %v", err)
+	}
+	if len(is) != 0 {
+		t.Errorf("Expected invite to be dropped before reaching the target, got %d invites.", len(is))
+	}
+
+	// the originator is told the invite was refused with an ERROR event, rather than it being rejected by the target.
+	mb := message.NewBuffer()
+	err = p.Error(mb)(1)(0)(invite2.InviteTypeParty)(invite2.CommandInviteTypeCreate)(1)(2)(transactionId)(ErrBlocked)
+	if err != nil {
+		t.Fatalf("Unable to report error: %v", err)
+	}
+	es := statusEvents[invite2.ErrorEventBody](t, mb)
+	if len(es) != 1 {
+		t.Fatalf("Expected 1 status event, got %d.", len(es))
+	}
+	if es[0].Type != invite2.EventInviteStatusTypeError || es[0].Body.Reason != invite2.ErrorReasonBlocked {
+		t.Errorf("Expected [%s] event with reason [%s], got [%s] with reason [%s].", invite2.EventInviteStatusTypeError, invite2.ErrorReasonBlocked, es[0].Type, es[0].Body.Reason)
+	}
+	if es[0].TransactionId != transactionId {
+		t.Errorf("Expected transaction [%s], got [%s].", transactionId, es[0].TransactionId)
+	}
+
+	err = block.NewProcessor(l, ctx).Remove(2, 1)
+	if err != nil {
+		t.Fatalf("Unable to unblock character: %v", err)
+	}
+	_, err = p.CreateAndEmit(1, 0, invite2.InviteTypeParty, 1, 2, uuid.New())
+	if err != nil {
+		t.Errorf("Unable to create invite once unblocked: %v", err)
+	}
+}
+
+func TestCreateDeclinedByPreference(t *testing.T) {
+	l, ctx, p := testProcessor(t)
+	_, err := preference.NewProcessor(l, ctx).Update(2, map[string]bool{invite2.InviteTypeTrade: true})
+	if err != nil {
+		t.Fatalf("Unable to update preferences: %v", err)
+	}
+
+	transactionId := uuid.New()
+	_, err = p.CreateAndEmit(1, 0, invite2.InviteTypeTrade, 1, 2, transactionId)
+	if !errors.Is(err, ErrPreferenceDeclined) {
+		t.Fatalf("Expected [%v], got [%v].", ErrPreferenceDeclined, err)
+	}
+	is, err := p.GetByCharacterId(2)
+	if err != nil {
+		t.Fatalf("Unable to get invites: %v", err)
+	}
+	if len(is) != 0 {
+		t.Errorf("Expected declined invite not to reach the target, got %d invites.", len(is))
+	}
+
+	// the originator is answered as though the target had rejected the invite.
+	mb := message.NewBuffer()
+	err = p.Error(mb)(1)(0)(invite2.InviteTypeTrade)(invite2.CommandInviteTypeCreate)(1)(2)(transactionId)(ErrPreferenceDeclined)
+	if err != nil {
+		t.Fatalf("Unable to report error: %v", err)
+	}
+	es := statusEvents[invite2.RejectedEventBody](t, mb)
+	if len(es) != 1 {
+		t.Fatalf("Expected 1 status event, got %d.", len(es))
+	}
+	if es[0].Type != invite2.EventInviteStatusTypeRejected || es[0].Body.Reason != invite2.RejectReasonPreferenceDeclined {
+		t.Errorf("Expected [%s] event with reason [%s], got [%s] with reason [%s].", invite2.EventInviteStatusTypeRejected, invite2.RejectReasonPreferenceDeclined, es[0].Type, es[0].Body.Reason)
+	}
+	if es[0].Body.OriginatorId != 1 || es[0].Body.TargetId != 2 || es[0].TransactionId != transactionId {
+		t.Errorf("Rejected event does not describe the declined invite.")
+	}
+
+	// other invite types still reach the target.
+	_, err = p.CreateAndEmit(1, 0, invite2.InviteTypeParty, 1, 2, uuid.New())
+	if err != nil {
+		t.Errorf("Unable to create invite of an allowed type: %v", err)
+	}
+}
+
+// outboxEvents decodes the status events held in the outbox for tm.
+func outboxEvents[E any](t *testing.T, tm tenant.Model) []invite2.StatusEvent[E] {
+	es, err := outbox.GetStore().Pending(1000)
+	if err != nil {
+		t.Fatalf("Unable to get outbox entries: %v", err)
+	}
+	results := make([]invite2.StatusEvent[E], 0)
+	for _, e := range es {
+		if e.Tenant().Id() != tm.Id() {
+			continue
+		}
+		var se invite2.StatusEvent[E]
+		err = json.Unmarshal(e.Message().Value, &se)
+		if err != nil {
+			t.Fatalf("Unable to decode status event: %v", err)
+		}
+		results = append(results, se)
+	}
+	return results
+}
+
+func TestEmitOnceRecordsFailure(t *testing.T) {
+	_, ctx, p := testProcessor(t)
+	tm := tenant.MustFromContext(ctx)
+	transactionId := uuid.New()
+	key := transactionId.String()
+	fail := func(p Processor, buf *message.Buffer, cause error) error {
+		return p.Error(buf)(1)(0)(invite2.InviteTypeBuddy)(invite2.CommandInviteTypeAccept)(0)(2)(transactionId)(cause)
+	}
+
+	err := p.EmitOnce(key)(fail)(func(p Processor, buf *message.Buffer) error {
+		_, err := p.Accept(buf)(1)(0)(invite2.InviteTypeBuddy)(2)(transactionId)
+		return err
+	})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected [%v], got [%v].", ErrNotFound, err)
+	}
+	es := outboxEvents[invite2.ErrorEventBody](t, tm)
+	if len(es) != 1 || es[0].Type != invite2.EventInviteStatusTypeError || es[0].Body.Reason != invite2.ErrorReasonNotFound {
+		t.Fatalf("Expected a single [%s] event with reason [%s].", invite2.EventInviteStatusTypeError, invite2.ErrorReasonNotFound)
+	}
+
+	// the redelivered command is answered with the recorded ERROR event, even though it would now succeed.
+	_, err = p.CreateAndEmit(1, 0, invite2.InviteTypeBuddy, 1, 2, uuid.New())
+	if err != nil {
+		t.Fatalf("Unable to create invite: %v", err)
+	}
+	err = p.EmitOnce(key)(fail)(func(p Processor, buf *message.Buffer) error {
+		t.Fatalf("Expected redelivered command not to be processed again.")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unable to emit recorded outcome: %v", err)
+	}
+	es = outboxEvents[invite2.ErrorEventBody](t, tm)
+	var failures int
+	for _, e := range es {
+		if e.Type == invite2.EventInviteStatusTypeError && e.TransactionId == transactionId {
+			failures++
+		}
+	}
+	if failures != 2 {
+		t.Errorf("Expected the recorded ERROR event to be emitted again, got %d.", failures)
+	}
+	is, err := p.GetByCharacterId(2)
+	if err != nil {
+		t.Fatalf("Unable to get invites: %v", err)
+	}
+	if len(is) != 1 || !is[0].Pending() {
+		t.Errorf("Expected the invite to remain pending.")
+	}
+}
@@ -6,13 +6,15 @@ import (
 	"github.com/Chronicle20/atlas-model/model"
 	"github.com/google/uuid"
 	"github.com/segmentio/kafka-go"
+	"time"
 )
 
-func createdStatusEventProvider(referenceId uint32, worldId byte, inviteType string, originatorId uint32, targetId uint32, transactionId uuid.UUID) model.Provider[[]kafka.Message] {
+func createdStatusEventProvider(inviteId uint32, referenceId uint32, worldId byte, inviteType string, originatorId uint32, targetId uint32, transactionId uuid.UUID) model.Provider[[]kafka.Message] {
 	key := producer.CreateKey(int(referenceId))
 	value := &invite2.StatusEvent[invite2.CreatedEventBody]{
 		WorldId:       worldId,
 		InviteType:    inviteType,
+		InviteId:      inviteId,
 		ReferenceId:   referenceId,
 		Type:          invite2.EventInviteStatusTypeCreated,
 		TransactionId: transactionId,
@@ -24,11 +26,12 @@ func createdStatusEventProvider(referenceId uint32, worldId byte, inviteType str
 	return producer.SingleMessageProvider(key, value)
 }
 
-func acceptedStatusEventProvider(referenceId uint32, worldId byte, inviteType string, originatorId uint32, targetId uint32, transactionId uuid.UUID) model.Provider[[]kafka.Message] {
+func acceptedStatusEventProvider(inviteId uint32, referenceId uint32, worldId byte, inviteType string, originatorId uint32, targetId uint32, transactionId uuid.UUID) model.Provider[[]kafka.Message] {
 	key := producer.CreateKey(int(referenceId))
 	value := &invite2.StatusEvent[invite2.AcceptedEventBody]{
 		WorldId:       worldId,
 		InviteType:    inviteType,
+		InviteId:      inviteId,
 		ReferenceId:   referenceId,
 		Type:          invite2.EventInviteStatusTypeAccepted,
 		TransactionId: transactionId,
@@ -40,17 +43,74 @@ func acceptedStatusEventProvider(referenceId uint32, worldId byte, inviteType st
 	return producer.SingleMessageProvider(key, value)
 }
 
-func rejectedStatusEventProvider(referenceId uint32, worldId byte, inviteType string, originatorId uint32, targetId uint32, transactionId uuid.UUID) model.Provider[[]kafka.Message] {
+func rejectedStatusEventProvider(inviteId uint32, referenceId uint32, worldId byte, inviteType string, originatorId uint32, targetId uint32, reason string, transactionId uuid.UUID) model.Provider[[]kafka.Message] {
 	key := producer.CreateKey(int(referenceId))
 	value := &invite2.StatusEvent[invite2.RejectedEventBody]{
 		WorldId:       worldId,
 		InviteType:    inviteType,
+		InviteId:      inviteId,
 		ReferenceId:   referenceId,
 		Type:          invite2.EventInviteStatusTypeRejected,
 		TransactionId: transactionId,
 		Body: invite2.RejectedEventBody{
 			OriginatorId: originatorId,
 			TargetId:     targetId,
+			Reason:       reason,
+		},
+	}
+	return producer.SingleMessageProvider(key, value)
+}
+
+func cancelledStatusEventProvider(inviteId uint32, referenceId uint32, worldId byte, inviteType string, originatorId uint32, targetId uint32, reason string, transactionId uuid.UUID) model.Provider[[]kafka.Message] {
+	key := producer.CreateKey(int(referenceId))
+	value := &invite2.StatusEvent[invite2.CancelledEventBody]{
+		WorldId:       worldId,
+		InviteType:    inviteType,
+		InviteId:      inviteId,
+		ReferenceId:   referenceId,
+		Type:          invite2.EventInviteStatusTypeCancelled,
+		TransactionId: transactionId,
+		Body: invite2.CancelledEventBody{
+			OriginatorId: originatorId,
+			TargetId:     targetId,
+			Reason:       reason,
+		},
+	}
+	return producer.SingleMessageProvider(key, value)
+}
+
+func expiredStatusEventProvider(inviteId uint32, referenceId uint32, worldId byte, inviteType string, originatorId uint32, targetId uint32, createdAt time.Time, ttl time.Duration, transactionId uuid.UUID) model.Provider[[]kafka.Message] {
+	key := producer.CreateKey(int(referenceId))
+	value := &invite2.StatusEvent[invite2.ExpiredEventBody]{
+		WorldId:       worldId,
+		InviteType:    inviteType,
+		InviteId:      inviteId,
+		ReferenceId:   referenceId,
+		Type:          invite2.EventInviteStatusTypeExpired,
+		TransactionId: transactionId,
+		Body: invite2.ExpiredEventBody{
+			OriginatorId: originatorId,
+			TargetId:     targetId,
+			CreatedAt:    createdAt,
+			Ttl:          ttl.Milliseconds(),
+		},
+	}
+	return producer.SingleMessageProvider(key, value)
+}
+
+func errorStatusEventProvider(referenceId uint32, worldId byte, inviteType string, commandType string, reason string, originatorId uint32, targetId uint32, transactionId uuid.UUID) model.Provider[[]kafka.Message] {
+	key := producer.CreateKey(int(referenceId))
+	value := &invite2.StatusEvent[invite2.ErrorEventBody]{
+		WorldId:       worldId,
+		InviteType:    inviteType,
+		ReferenceId:   referenceId,
+		Type:          invite2.EventInviteStatusTypeError,
+		TransactionId: transactionId,
+		Body: invite2.ErrorEventBody{
+			CommandType:  commandType,
+			Reason:       reason,
+			OriginatorId: originatorId,
+			TargetId:     targetId,
 		},
 	}
 	return producer.SingleMessageProvider(key, value)
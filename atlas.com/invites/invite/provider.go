@@ -0,0 +1,132 @@
+package invite
+
+import (
+	"atlas-invites/database"
+	"github.com/Chronicle20/atlas-model/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+func getById(tenantId uuid.UUID) func(id uint32) database.EntityProvider[Entity] {
+	return func(id uint32) database.EntityProvider[Entity] {
+		return func(db *gorm.DB) model.Provider[Entity] {
+			return database.Query[Entity](db, map[string]interface{}{"tenant_id": tenantId, "id": id})
+		}
+	}
+}
+
+func getByReference(tenantId uuid.UUID) func(targetId uint32) func(inviteType string) func(referenceId uint32) database.EntityProvider[Entity] {
+	return func(targetId uint32) func(inviteType string) func(referenceId uint32) database.EntityProvider[Entity] {
+		return func(inviteType string) func(referenceId uint32) database.EntityProvider[Entity] {
+			return func(referenceId uint32) database.EntityProvider[Entity] {
+				return func(db *gorm.DB) model.Provider[Entity] {
+					return database.Query[Entity](db, map[string]interface{}{"tenant_id": tenantId, "target_id": targetId, "invite_type": inviteType, "reference_id": referenceId, "status": string(StatusPending)})
+				}
+			}
+		}
+	}
+}
+
+func getByOriginator(tenantId uuid.UUID) func(targetId uint32) func(inviteType string) func(originatorId uint32) database.EntityProvider[Entity] {
+	return func(targetId uint32) func(inviteType string) func(originatorId uint32) database.EntityProvider[Entity] {
+		return func(inviteType string) func(originatorId uint32) database.EntityProvider[Entity] {
+			return func(originatorId uint32) database.EntityProvider[Entity] {
+				return func(db *gorm.DB) model.Provider[Entity] {
+					return database.Query[Entity](db, map[string]interface{}{"tenant_id": tenantId, "target_id": targetId, "invite_type": inviteType, "originator_id": originatorId, "status": string(StatusPending)})
+				}
+			}
+		}
+	}
+}
+
+func getForCharacter(tenantId uuid.UUID) func(characterId uint32) database.EntityProvider[[]Entity] {
+	return func(characterId uint32) database.EntityProvider[[]Entity] {
+		return func(db *gorm.DB) model.Provider[[]Entity] {
+			return database.SliceQuery[Entity](db, map[string]interface{}{"tenant_id": tenantId, "target_id": characterId, "status": string(StatusPending)})
+		}
+	}
+}
+
+func getForTarget(tenantId uuid.UUID) func(targetId uint32) func(inviteType string) database.EntityProvider[[]Entity] {
+	return func(targetId uint32) func(inviteType string) database.EntityProvider[[]Entity] {
+		return func(inviteType string) database.EntityProvider[[]Entity] {
+			return func(db *gorm.DB) model.Provider[[]Entity] {
+				return database.SliceQuery[Entity](db, map[string]interface{}{"tenant_id": tenantId, "target_id": targetId, "invite_type": inviteType, "status": string(StatusPending)})
+			}
+		}
+	}
+}
+
+func getForOriginator(tenantId uuid.UUID) func(originatorId uint32) database.EntityProvider[[]Entity] {
+	return func(originatorId uint32) database.EntityProvider[[]Entity] {
+		return func(db *gorm.DB) model.Provider[[]Entity] {
+			return database.SliceQuery[Entity](db, map[string]interface{}{"tenant_id": tenantId, "originator_id": originatorId, "status": string(StatusPending)})
+		}
+	}
+}
+
+func getInvolving(tenantId uuid.UUID) func(inviteType string) func(characterIds ...uint32) database.EntityProvider[[]Entity] {
+	return func(inviteType string) func(characterIds ...uint32) database.EntityProvider[[]Entity] {
+		return func(characterIds ...uint32) database.EntityProvider[[]Entity] {
+			return func(db *gorm.DB) model.Provider[[]Entity] {
+				var results []Entity
+				err := db.Where("tenant_id = ? AND invite_type = ? AND status = ? AND (originator_id IN ? OR target_id IN ?)", tenantId, inviteType, string(StatusPending), characterIds, characterIds).Find(&results).Error
+				if err != nil {
+					return model.ErrorProvider[[]Entity](err)
+				}
+				return model.FixedProvider(results)
+			}
+		}
+	}
+}
+
+func getForReference(tenantId uuid.UUID) func(inviteType string) func(referenceId uint32) database.EntityProvider[[]Entity] {
+	return func(inviteType string) func(referenceId uint32) database.EntityProvider[[]Entity] {
+		return func(referenceId uint32) database.EntityProvider[[]Entity] {
+			return func(db *gorm.DB) model.Provider[[]Entity] {
+				return database.SliceQuery[Entity](db, map[string]interface{}{"tenant_id": tenantId, "invite_type": inviteType, "reference_id": referenceId, "status": string(StatusPending)})
+			}
+		}
+	}
+}
+
+func getExpiredAt(now time.Time) database.EntityProvider[[]Entity] {
+	return func(db *gorm.DB) model.Provider[[]Entity] {
+		var results []Entity
+		err := db.Where("status = ? AND expires_at <= ?", string(StatusPending), now).Find(&results).Error
+		if err != nil {
+			return model.ErrorProvider[[]Entity](err)
+		}
+		return model.FixedProvider(results)
+	}
+}
+
+func getCooldownsActiveAt(tenantId uuid.UUID) func(originatorId uint32) func(targetId uint32) func(now time.Time) database.EntityProvider[[]CooldownEntity] {
+	return func(originatorId uint32) func(targetId uint32) func(now time.Time) database.EntityProvider[[]CooldownEntity] {
+		return func(targetId uint32) func(now time.Time) database.EntityProvider[[]CooldownEntity] {
+			return func(now time.Time) database.EntityProvider[[]CooldownEntity] {
+				return func(db *gorm.DB) model.Provider[[]CooldownEntity] {
+					var results []CooldownEntity
+					err := db.Where("tenant_id = ? AND originator_id = ? AND target_id = ? AND expires_at > ?", tenantId, originatorId, targetId, now).Find(&results).Error
+					if err != nil {
+						return model.ErrorProvider[[]CooldownEntity](err)
+					}
+					return model.FixedProvider(results)
+				}
+			}
+		}
+	}
+}
+
+type pendingCount struct {
+	TenantId   uuid.UUID
+	InviteType string
+	Count      int
+}
+
+func countPending(db *gorm.DB) ([]pendingCount, error) {
+	var results []pendingCount
+	err := db.Model(&Entity{}).Where("status = ?", string(StatusPending)).Select("tenant_id, invite_type, COUNT(*) AS count").Group("tenant_id, invite_type").Scan(&results).Error
+	return results, err
+}
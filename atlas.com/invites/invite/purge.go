@@ -0,0 +1,88 @@
+package invite
+
+import (
+	character2 "atlas-invites/kafka/message/character"
+	invite2 "atlas-invites/kafka/message/invite"
+	"sync"
+)
+
+// PurgePolicy resolves which invite types are dropped when a character becomes unavailable. It is keyed by the
+// character status event type (LOGOUT, CHANNEL_CHANGED, DELETED).
+type PurgePolicy struct {
+	events map[string]map[string]bool
+}
+
+// DefaultPurgePolicy drops every invite when a character is deleted, every invite except buddy requests when a
+// character logs out, and trades when a character changes channel.
+func DefaultPurgePolicy() PurgePolicy {
+	return NewPurgePolicy(map[string][]string{
+		character2.EventCharacterStatusTypeLogout: {
+			invite2.InviteTypeFamily, invite2.InviteTypeFamilySummon, invite2.InviteTypeMessenger, invite2.InviteTypeTrade,
+			invite2.InviteTypeParty, invite2.InviteTypeGuild, invite2.InviteTypeAlliance,
+		},
+		character2.EventCharacterStatusTypeChannelChanged: {
+			invite2.InviteTypeTrade,
+		},
+		character2.EventCharacterStatusTypeDeleted: {
+			invite2.InviteTypeBuddy, invite2.InviteTypeFamily, invite2.InviteTypeFamilySummon, invite2.InviteTypeMessenger,
+			invite2.InviteTypeTrade, invite2.InviteTypeParty, invite2.InviteTypeGuild, invite2.InviteTypeAlliance,
+		},
+	})
+}
+
+func NewPurgePolicy(events map[string][]string) PurgePolicy {
+	p := PurgePolicy{events: make(map[string]map[string]bool)}
+	for eventType, inviteTypes := range events {
+		p.events[eventType] = make(map[string]bool)
+		for _, inviteType := range inviteTypes {
+			p.events[eventType][inviteType] = true
+		}
+	}
+	return p
+}
+
+// Purges reports whether invites of inviteType are dropped when a character produces a status event of eventType.
+func (p PurgePolicy) Purges(eventType string, inviteType string) bool {
+	return p.events[eventType][inviteType]
+}
+
+// PurgePolicyFromEnv reads INVITE_PURGE_CONFIG with loadJSONConfig. It maps a character status event type to the invite
+// types it purges. Event types present in the configuration replace the defaults for that event; the rest keep
+// DefaultPurgePolicy.
+func PurgePolicyFromEnv() (PurgePolicy, error) {
+	p := DefaultPurgePolicy()
+	var c map[string][]string
+	ok, err := loadJSONConfig("INVITE_PURGE_CONFIG", &c)
+	if err != nil {
+		return PurgePolicy{}, err
+	}
+	if !ok {
+		return p, nil
+	}
+	for eventType, types := range NewPurgePolicy(c).events {
+		p.events[eventType] = types
+	}
+	return p, nil
+}
+
+var purgePolicy PurgePolicy
+var purgeOnce sync.Once
+
+// InitPurgePolicy sets the PurgePolicy used by the service, and panics if one is already set.
+func InitPurgePolicy(p PurgePolicy) {
+	set := false
+	purgeOnce.Do(func() {
+		purgePolicy = p
+		set = true
+	})
+	if !set {
+		panic("invite purge policy already set")
+	}
+}
+
+func GetPurgePolicy() PurgePolicy {
+	purgeOnce.Do(func() {
+		purgePolicy = DefaultPurgePolicy()
+	})
+	return purgePolicy
+}
@@ -0,0 +1,373 @@
+package invite
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
+	"sync"
+	"time"
+)
+
+const (
+	RateLimitScopeOriginator = "originator"
+	RateLimitScopeTarget     = "target"
+	RateLimitScopeCooldown   = "cooldown"
+
+	pruneInterval = time.Minute
+)
+
+// BucketPolicy describes a token bucket holding up to capacity tokens, refilled with one token every refill. A zero
+// capacity disables the bucket.
+type BucketPolicy struct {
+	capacity int
+	refill   time.Duration
+}
+
+func (b BucketPolicy) enabled() bool {
+	return b.capacity > 0 && b.refill > 0
+}
+
+// CooldownPolicy escalates repeat offenders. Once an originator is denied threshold times within window, it is refused
+// outright for base, doubling with each further cooldown up to max. An originator which goes reset without a cooldown
+// starts over at base.
+type CooldownPolicy struct {
+	threshold int
+	window    time.Duration
+	base      time.Duration
+	max       time.Duration
+	reset     time.Duration
+}
+
+func (c CooldownPolicy) enabled() bool {
+	return c.threshold > 0 && c.base > 0
+}
+
+func (c CooldownPolicy) duration(strikes int) time.Duration {
+	d := c.base
+	for i := 1; i < strikes && d < c.max; i++ {
+		d *= 2
+	}
+	if c.max > 0 && d > c.max {
+		return c.max
+	}
+	return d
+}
+
+// RateLimitPolicy resolves the buckets applied to invite creation. Invites are limited per originator and, optionally,
+// per originator and target pair. Invite type specific buckets take precedence over the defaults.
+type RateLimitPolicy struct {
+	originator BucketPolicy
+	target     BucketPolicy
+	types      map[string]typeRateLimitPolicy
+	cooldown   CooldownPolicy
+}
+
+type typeRateLimitPolicy struct {
+	originator *BucketPolicy
+	target     *BucketPolicy
+}
+
+// DefaultRateLimitPolicy disables rate limiting, leaving every bucket and the cooldown without capacity.
+func DefaultRateLimitPolicy() RateLimitPolicy {
+	return RateLimitPolicy{
+		types: make(map[string]typeRateLimitPolicy),
+	}
+}
+
+func (p RateLimitPolicy) Originator(inviteType string) BucketPolicy {
+	if tp, ok := p.types[inviteType]; ok && tp.originator != nil {
+		return *tp.originator
+	}
+	return p.originator
+}
+
+func (p RateLimitPolicy) Target(inviteType string) BucketPolicy {
+	if tp, ok := p.types[inviteType]; ok && tp.target != nil {
+		return *tp.target
+	}
+	return p.target
+}
+
+type BucketConfig struct {
+	Capacity int    `json:"capacity"`
+	Refill   string `json:"refill"`
+}
+
+type TypeRateLimitConfig struct {
+	Originator *BucketConfig `json:"originator"`
+	Target     *BucketConfig `json:"target"`
+}
+
+type CooldownConfig struct {
+	Threshold int    `json:"threshold"`
+	Window    string `json:"window"`
+	Base      string `json:"base"`
+	Max       string `json:"max"`
+	Reset     string `json:"reset"`
+}
+
+type RateLimitConfig struct {
+	Originator *BucketConfig                  `json:"originator"`
+	Target     *BucketConfig                  `json:"target"`
+	Types      map[string]TypeRateLimitConfig `json:"types"`
+	Cooldown   *CooldownConfig                `json:"cooldown"`
+}
+
+// ParseRateLimitPolicy builds a RateLimitPolicy from a configuration whose durations are expressed as Go duration
+// strings. Sections absent from the configuration keep DefaultRateLimitPolicy.
+func ParseRateLimitPolicy(c RateLimitConfig) (RateLimitPolicy, error) {
+	p := DefaultRateLimitPolicy()
+	var err error
+	if c.Originator != nil {
+		p.originator, err = parseBucket(*c.Originator)
+		if err != nil {
+			return RateLimitPolicy{}, err
+		}
+	}
+	if c.Target != nil {
+		p.target, err = parseBucket(*c.Target)
+		if err != nil {
+			return RateLimitPolicy{}, err
+		}
+	}
+	for inviteType, tc := range c.Types {
+		tp := typeRateLimitPolicy{}
+		if tc.Originator != nil {
+			var b BucketPolicy
+			b, err = parseBucket(*tc.Originator)
+			if err != nil {
+				return RateLimitPolicy{}, err
+			}
+			tp.originator = &b
+		}
+		if tc.Target != nil {
+			var b BucketPolicy
+			b, err = parseBucket(*tc.Target)
+			if err != nil {
+				return RateLimitPolicy{}, err
+			}
+			tp.target = &b
+		}
+		p.types[inviteType] = tp
+	}
+	if c.Cooldown != nil {
+		p.cooldown = CooldownPolicy{threshold: c.Cooldown.Threshold}
+		for _, d := range []struct {
+			val string
+			dst *time.Duration
+		}{
+			{c.Cooldown.Window, &p.cooldown.window},
+			{c.Cooldown.Base, &p.cooldown.base},
+			{c.Cooldown.Max, &p.cooldown.max},
+			{c.Cooldown.Reset, &p.cooldown.reset},
+		} {
+			if d.val == "" {
+				continue
+			}
+			*d.dst, err = time.ParseDuration(d.val)
+			if err != nil {
+				return RateLimitPolicy{}, err
+			}
+		}
+	}
+	return p, nil
+}
+
+func parseBucket(c BucketConfig) (BucketPolicy, error) {
+	b := BucketPolicy{capacity: c.Capacity}
+	if c.Refill != "" {
+		var err error
+		b.refill, err = time.ParseDuration(c.Refill)
+		if err != nil {
+			return BucketPolicy{}, err
+		}
+	}
+	return b, nil
+}
+
+// RateLimitPolicyFromEnv reads INVITE_RATE_LIMIT_CONFIG with loadJSONConfig. When it is unset DefaultRateLimitPolicy
+// applies.
+func RateLimitPolicyFromEnv() (RateLimitPolicy, error) {
+	var c RateLimitConfig
+	ok, err := loadJSONConfig("INVITE_RATE_LIMIT_CONFIG", &c)
+	if err != nil {
+		return RateLimitPolicy{}, err
+	}
+	if !ok {
+		return DefaultRateLimitPolicy(), nil
+	}
+	return ParseRateLimitPolicy(c)
+}
+
+type bucketKey struct {
+	tenantId     uuid.UUID
+	scope        string
+	inviteType   string
+	originatorId uint32
+	targetId     uint32
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type offenderKey struct {
+	tenantId     uuid.UUID
+	originatorId uint32
+}
+
+type offender struct {
+	denials       int
+	windowStart   time.Time
+	strikes       int
+	cooldownUntil time.Time
+}
+
+// RateLimiter enforces a RateLimitPolicy. State is held in memory, so limits apply per service instance and the
+// effective limit scales with the number of instances.
+type RateLimiter struct {
+	lock      sync.Mutex
+	policy    RateLimitPolicy
+	buckets   map[bucketKey]*bucket
+	offenders map[offenderKey]*offender
+	lastPrune time.Time
+}
+
+func NewRateLimiter(p RateLimitPolicy) *RateLimiter {
+	return &RateLimiter{
+		policy:    p,
+		buckets:   make(map[bucketKey]*bucket),
+		offenders: make(map[offenderKey]*offender),
+		lastPrune: time.Now(),
+	}
+}
+
+// Allow takes a token for an invite from originatorId to targetId. When the invite is refused, Allow returns the scope
+// which refused it (RateLimitScopeOriginator, RateLimitScopeTarget or RateLimitScopeCooldown) and false.
+func (r *RateLimiter) Allow(t tenant.Model, inviteType string, originatorId uint32, targetId uint32) (string, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	if now.Sub(r.lastPrune) >= pruneInterval {
+		r.prune(now)
+	}
+
+	ok := offenderKey{tenantId: t.Id(), originatorId: originatorId}
+	if o, exists := r.offenders[ok]; exists && now.Before(o.cooldownUntil) {
+		return RateLimitScopeCooldown, false
+	}
+
+	ob := r.policy.Originator(inviteType)
+	tb := r.policy.Target(inviteType)
+	okey := bucketKey{tenantId: t.Id(), scope: RateLimitScopeOriginator, inviteType: inviteType, originatorId: originatorId}
+	tkey := bucketKey{tenantId: t.Id(), scope: RateLimitScopeTarget, inviteType: inviteType, originatorId: originatorId, targetId: targetId}
+
+	// both buckets must have a token before either is taken, so a refusal by one does not drain the other.
+	if ob.enabled() && r.available(okey, ob, now) < 1 {
+		r.deny(ok, now)
+		return RateLimitScopeOriginator, false
+	}
+	if tb.enabled() && r.available(tkey, tb, now) < 1 {
+		r.deny(ok, now)
+		return RateLimitScopeTarget, false
+	}
+	if ob.enabled() {
+		r.buckets[okey].tokens--
+	}
+	if tb.enabled() {
+		r.buckets[tkey].tokens--
+	}
+	return "", true
+}
+
+func (r *RateLimiter) available(k bucketKey, p BucketPolicy, now time.Time) float64 {
+	b, ok := r.buckets[k]
+	if !ok {
+		b = &bucket{tokens: float64(p.capacity), last: now}
+		r.buckets[k] = b
+		return b.tokens
+	}
+	b.tokens += float64(now.Sub(b.last)) / float64(p.refill)
+	if b.tokens > float64(p.capacity) {
+		b.tokens = float64(p.capacity)
+	}
+	b.last = now
+	return b.tokens
+}
+
+func (r *RateLimiter) deny(k offenderKey, now time.Time) {
+	c := r.policy.cooldown
+	if !c.enabled() {
+		return
+	}
+	o, ok := r.offenders[k]
+	if !ok {
+		o = &offender{windowStart: now}
+		r.offenders[k] = o
+	}
+	if now.Sub(o.windowStart) > c.window {
+		o.denials = 0
+		o.windowStart = now
+	}
+	o.denials++
+	if o.denials < c.threshold {
+		return
+	}
+	if c.reset > 0 && !o.cooldownUntil.IsZero() && now.Sub(o.cooldownUntil) > c.reset {
+		o.strikes = 0
+	}
+	o.strikes++
+	o.denials = 0
+	o.windowStart = now
+	o.cooldownUntil = now.Add(c.duration(o.strikes))
+}
+
+// prune forgets buckets which have refilled completely and offenders whose record has lapsed, as neither affects
+// future decisions.
+func (r *RateLimiter) prune(now time.Time) {
+	for k, b := range r.buckets {
+		p := r.policy.Originator(k.inviteType)
+		if k.scope == RateLimitScopeTarget {
+			p = r.policy.Target(k.inviteType)
+		}
+		if !p.enabled() || now.Sub(b.last) >= time.Duration(p.capacity)*p.refill {
+			delete(r.buckets, k)
+		}
+	}
+	c := r.policy.cooldown
+	for k, o := range r.offenders {
+		if now.Before(o.cooldownUntil) {
+			continue
+		}
+		if o.strikes > 0 && (c.reset == 0 || now.Sub(o.cooldownUntil) <= c.reset) {
+			continue
+		}
+		if now.Sub(o.windowStart) > c.window {
+			delete(r.offenders, k)
+		}
+	}
+	r.lastPrune = now
+}
+
+var rateLimiter *RateLimiter
+var rateLimiterOnce sync.Once
+
+// InitRateLimiter sets the RateLimiter used by the service, and panics if one is already set.
+func InitRateLimiter(r *RateLimiter) {
+	set := false
+	rateLimiterOnce.Do(func() {
+		rateLimiter = r
+		set = true
+	})
+	if !set {
+		panic("invite rate limiter already set")
+	}
+}
+
+func GetRateLimiter() *RateLimiter {
+	rateLimiterOnce.Do(func() {
+		rateLimiter = NewRateLimiter(DefaultRateLimitPolicy())
+	})
+	return rateLimiter
+}
@@ -0,0 +1,118 @@
+package invite
+
+import (
+	"testing"
+	"time"
+)
+
+// elapse moves every bucket of r back by d, as though d had passed since each was last refilled.
+func elapse(r *RateLimiter, d time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, b := range r.buckets {
+		b.last = b.last.Add(-d)
+	}
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	tm := testTenant(t)
+	r := NewRateLimiter(RateLimitPolicy{originator: BucketPolicy{capacity: 3, refill: time.Hour}})
+
+	for i := 0; i < 3; i++ {
+		if _, ok := r.Allow(tm, "BUDDY", 1, uint32(10+i)); !ok {
+			t.Fatalf("Expected invite %d of the burst to be allowed.", i+1)
+		}
+	}
+	scope, ok := r.Allow(tm, "BUDDY", 1, 20)
+	if ok {
+		t.Fatalf("Expected invite beyond the burst to be refused.")
+	}
+	if scope != RateLimitScopeOriginator {
+		t.Errorf("Expected scope [%s], got [%s].", RateLimitScopeOriginator, scope)
+	}
+	if _, ok = r.Allow(tm, "PARTY", 1, 20); !ok {
+		t.Errorf("Expected invite of another type to be allowed.")
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	tm := testTenant(t)
+	r := NewRateLimiter(RateLimitPolicy{originator: BucketPolicy{capacity: 2, refill: time.Minute}})
+
+	for i := 0; i < 2; i++ {
+		r.Allow(tm, "BUDDY", 1, 2)
+	}
+	if _, ok := r.Allow(tm, "BUDDY", 1, 2); ok {
+		t.Fatalf("Expected empty bucket to refuse invite.")
+	}
+
+	elapse(r, 30*time.Second)
+	if _, ok := r.Allow(tm, "BUDDY", 1, 2); ok {
+		t.Fatalf("Expected bucket with half a token to refuse invite.")
+	}
+
+	elapse(r, 30*time.Second)
+	if _, ok := r.Allow(tm, "BUDDY", 1, 2); !ok {
+		t.Fatalf("Expected refilled token to allow invite.")
+	}
+	if _, ok := r.Allow(tm, "BUDDY", 1, 2); ok {
+		t.Fatalf("Expected bucket to regain only one token.")
+	}
+
+	// a bucket never holds more than its capacity, however long it is left.
+	elapse(r, time.Hour)
+	for i := 0; i < 2; i++ {
+		if _, ok := r.Allow(tm, "BUDDY", 1, 2); !ok {
+			t.Fatalf("Expected invite %d after a full refill to be allowed.", i+1)
+		}
+	}
+	if _, ok := r.Allow(tm, "BUDDY", 1, 2); ok {
+		t.Fatalf("Expected bucket to refill only to capacity.")
+	}
+}
+
+func TestRateLimiterTargetBucket(t *testing.T) {
+	tm := testTenant(t)
+	r := NewRateLimiter(RateLimitPolicy{
+		originator: BucketPolicy{capacity: 3, refill: time.Hour},
+		target:     BucketPolicy{capacity: 1, refill: time.Hour},
+	})
+
+	r.Allow(tm, "BUDDY", 1, 2)
+	scope, ok := r.Allow(tm, "BUDDY", 1, 2)
+	if ok || scope != RateLimitScopeTarget {
+		t.Fatalf("Expected target bucket to refuse invite, got scope [%s].", scope)
+	}
+	// the refusal by the target bucket does not take a token from the originator bucket.
+	for _, targetId := range []uint32{3, 4} {
+		if _, ok = r.Allow(tm, "BUDDY", 1, targetId); !ok {
+			t.Fatalf("Expected invite to target [%d] to be allowed.", targetId)
+		}
+	}
+}
+
+func TestRateLimiterTenantIsolation(t *testing.T) {
+	a := testTenant(t)
+	b := testTenant(t)
+	r := NewRateLimiter(RateLimitPolicy{originator: BucketPolicy{capacity: 1, refill: time.Hour}})
+
+	if _, ok := r.Allow(a, "BUDDY", 1, 2); !ok {
+		t.Fatalf("Expected first invite in tenant [%s] to be allowed.", a.Id())
+	}
+	if _, ok := r.Allow(a, "BUDDY", 1, 2); ok {
+		t.Fatalf("Expected second invite in tenant [%s] to be refused.", a.Id())
+	}
+	if _, ok := r.Allow(b, "BUDDY", 1, 2); !ok {
+		t.Errorf("Expected invite by the same originator in tenant [%s] to be allowed.", b.Id())
+	}
+}
+
+func TestRateLimiterDisabledByDefault(t *testing.T) {
+	tm := testTenant(t)
+	r := NewRateLimiter(DefaultRateLimitPolicy())
+	for i := 0; i < 100; i++ {
+		if _, ok := r.Allow(tm, "BUDDY", 1, 2); !ok {
+			t.Fatalf("Expected default policy to allow invite %d.", i+1)
+		}
+	}
+}
@@ -1,51 +1,187 @@
 package invite
 
 import (
-	"errors"
+	"atlas-invites/kafka/message"
+	invite2 "atlas-invites/kafka/message/invite"
+	"atlas-invites/outbox"
+	"atlas-invites/transaction"
+	"cmp"
 	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"maps"
+	"slices"
 	"sync"
 	"time"
 )
 
-type Registry struct {
-	lock           sync.Mutex
-	tenantInviteId map[tenant.Model]uint32
-	inviteReg      map[tenant.Model]map[uint32]map[string][]Model
-	tenantLock     map[tenant.Model]*sync.RWMutex
+// Registry tracks pending invites and re-invite cooldowns. Implementations must be safe for concurrent use. Create
+// records an invite created at, expiring ttl later, and returns the existing invite along with ErrAlreadyPending when
+// the target already holds an invite of the same type and reference. Otherwise it applies the ExclusivityPolicy and
+// CapacityPolicy atomically with the creation, returning the invites it evicted, ErrExclusivityConflict or
+// ErrCapacityExceeded. Restore records a pending invite under the id it was first allocated, without applying either
+// policy, and reports ErrAlreadyPending when that id is already pending. Resolve and ResolveById report ErrNotFound
+// unless the invite is pending. GetCooldowns returns only cooldowns which have not yet expired. Transaction runs f with
+// a Registry, outbox.Store and message.Recorder whose changes are committed together.
+type Registry interface {
+	Create(t tenant.Model, originatorId uint32, worldId byte, targetId uint32, inviteType string, referenceId uint32, at time.Time, ttl time.Duration, xp ExclusivityPolicy, cp CapacityPolicy) (Model, []Eviction, error)
+	Restore(t tenant.Model, id uint32, originatorId uint32, worldId byte, targetId uint32, inviteType string, referenceId uint32, at time.Time, ttl time.Duration) (Model, error)
+	GetById(t tenant.Model, id uint32) (Model, error)
+	GetByOriginator(t tenant.Model, actorId uint32, inviteType string, originatorId uint32) (Model, error)
+	GetByReference(t tenant.Model, actorId uint32, inviteType string, referenceId uint32) (Model, error)
+	GetForCharacter(t tenant.Model, characterId uint32) ([]Model, error)
+	GetForOriginator(t tenant.Model, originatorId uint32) ([]Model, error)
+	GetForReference(t tenant.Model, inviteType string, referenceId uint32) ([]Model, error)
+	Resolve(t tenant.Model, actorId uint32, inviteType string, originatorId uint32, status Status, at time.Time) (Model, error)
+	ResolveById(t tenant.Model, id uint32, status Status, at time.Time) (Model, error)
+	GetExpired() ([]Model, error)
+	PruneResolved(before time.Time) error
+	CountPending() (map[uuid.UUID]map[string]int, error)
+	AddCooldown(t tenant.Model, originatorId uint32, targetId uint32, inviteType string, expiresAt time.Time) error
+	GetCooldowns(t tenant.Model, originatorId uint32, targetId uint32) ([]Cooldown, error)
+	DeleteExpiredCooldowns() error
+	Transaction(f func(r Registry, s outbox.Store, rc message.Recorder) error) error
 }
 
-var registry *Registry
+var registry Registry
 var once sync.Once
 
-func GetRegistry() *Registry {
+// InitRegistry sets the Registry used by the service, and panics if one is already set.
+func InitRegistry(r Registry) {
+	set := false
 	once.Do(func() {
-		registry = &Registry{}
-		registry.tenantInviteId = make(map[tenant.Model]uint32)
-		registry.inviteReg = make(map[tenant.Model]map[uint32]map[string][]Model)
-		registry.tenantLock = make(map[tenant.Model]*sync.RWMutex)
+		registry = r
+		set = true
+	})
+	if !set {
+		panic("invite registry already set")
+	}
+}
+
+// GetRegistry returns the configured Registry, defaulting to an in-memory implementation.
+func GetRegistry() Registry {
+	once.Do(func() {
+		registry = NewInMemoryRegistry()
 	})
 	return registry
 }
 
-func (r *Registry) Create(t tenant.Model, originatorId uint32, worldId byte, targetId uint32, inviteType string, referenceId uint32) Model {
-	var inviteId uint32
-	var inviteReg map[uint32]map[string][]Model
-	var tenantLock *sync.RWMutex
-	var ok bool
+type referenceKey struct {
+	inviteType  string
+	referenceId uint32
+}
+
+// tenantIndex holds secondary indexes over a tenant's pending invites, along with its resolved invites by id. It is
+// guarded by the tenant lock.
+type tenantIndex struct {
+	byId         map[uint32]Model
+	byOriginator map[uint32]map[uint32]Model
+	byReference  map[referenceKey]map[uint32]Model
+	resolved     map[uint32]Model
+}
+
+func newTenantIndex() *tenantIndex {
+	return &tenantIndex{
+		byId:         make(map[uint32]Model),
+		byOriginator: make(map[uint32]map[uint32]Model),
+		byReference:  make(map[referenceKey]map[uint32]Model),
+		resolved:     make(map[uint32]Model),
+	}
+}
+
+func (ti *tenantIndex) add(m Model) {
+	ti.byId[m.Id()] = m
+	if _, ok := ti.byOriginator[m.OriginatorId()]; !ok {
+		ti.byOriginator[m.OriginatorId()] = make(map[uint32]Model)
+	}
+	ti.byOriginator[m.OriginatorId()][m.Id()] = m
+	rk := referenceKey{m.Type(), m.ReferenceId()}
+	if _, ok := ti.byReference[rk]; !ok {
+		ti.byReference[rk] = make(map[uint32]Model)
+	}
+	ti.byReference[rk][m.Id()] = m
+}
+
+func (ti *tenantIndex) remove(m Model) {
+	delete(ti.byId, m.Id())
+	if is, ok := ti.byOriginator[m.OriginatorId()]; ok {
+		delete(is, m.Id())
+		if len(is) == 0 {
+			delete(ti.byOriginator, m.OriginatorId())
+		}
+	}
+	rk := referenceKey{m.Type(), m.ReferenceId()}
+	if is, ok := ti.byReference[rk]; ok {
+		delete(is, m.Id())
+		if len(is) == 0 {
+			delete(ti.byReference, rk)
+		}
+	}
+}
+
+type cooldownKey struct {
+	tenant       tenant.Model
+	originatorId uint32
+	targetId     uint32
+	inviteType   string
+}
+
+type retiredKey struct {
+	tenant tenant.Model
+	id     uint32
+	at     time.Time
+}
+
+type InMemoryRegistry struct {
+	lock           sync.Mutex
+	txLock         sync.Mutex
+	tenantInviteId map[tenant.Model]uint32
+	inviteReg      map[tenant.Model]map[uint32]map[string][]Model
+	inviteIdx      map[tenant.Model]*tenantIndex
+	tenantLock     map[tenant.Model]*sync.RWMutex
+	expiry         *expiryIndex
+	cooldownLock   sync.Mutex
+	cooldowns      map[cooldownKey]Cooldown
+	retiredLock    sync.Mutex
+	retired        []retiredKey
+}
+
+func NewInMemoryRegistry() *InMemoryRegistry {
+	return &InMemoryRegistry{
+		tenantInviteId: make(map[tenant.Model]uint32),
+		inviteReg:      make(map[tenant.Model]map[uint32]map[string][]Model),
+		inviteIdx:      make(map[tenant.Model]*tenantIndex),
+		tenantLock:     make(map[tenant.Model]*sync.RWMutex),
+		expiry:         newExpiryIndex(),
+		cooldowns:      make(map[cooldownKey]Cooldown),
+	}
+}
+
+// getTenantLock returns the lock guarding a tenant's invites, initializing the tenant's registries on first use.
+func (r *InMemoryRegistry) getTenantLock(t tenant.Model) *sync.RWMutex {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if tl, ok := r.tenantLock[t]; ok {
+		return tl
+	}
+	tl := &sync.RWMutex{}
+	r.inviteReg[t] = make(map[uint32]map[string][]Model)
+	r.inviteIdx[t] = newTenantIndex()
+	r.tenantLock[t] = tl
+	return tl
+}
+
+func (r *InMemoryRegistry) Create(t tenant.Model, originatorId uint32, worldId byte, targetId uint32, inviteType string, referenceId uint32, at time.Time, ttl time.Duration, xp ExclusivityPolicy, cp CapacityPolicy) (Model, []Eviction, error) {
+	tenantLock := r.getTenantLock(t)
 
 	r.lock.Lock()
-	if inviteId, ok = r.tenantInviteId[t]; ok {
+	inviteId, ok := r.tenantInviteId[t]
+	if ok {
 		inviteId += 1
-		inviteReg = r.inviteReg[t]
-		tenantLock = r.tenantLock[t]
 	} else {
 		inviteId = StartInviteId
-		inviteReg = make(map[uint32]map[string][]Model)
-		tenantLock = &sync.RWMutex{}
 	}
 	r.tenantInviteId[t] = inviteId
-	r.inviteReg[t] = inviteReg
-	r.tenantLock[t] = tenantLock
 	r.lock.Unlock()
 
 	m := Model{
@@ -56,7 +192,10 @@ func (r *Registry) Create(t tenant.Model, originatorId uint32, worldId byte, tar
 		originatorId: originatorId,
 		targetId:     targetId,
 		worldId:      worldId,
-		age:          time.Now(),
+		age:          at,
+		expiresAt:    at.Add(ttl),
+		status:       StatusPending,
+		history:      []Transition{{status: StatusPending, at: at}},
 	}
 
 	tenantLock.Lock()
@@ -71,155 +210,502 @@ func (r *Registry) Create(t tenant.Model, originatorId uint32, worldId byte, tar
 
 	for _, i := range r.inviteReg[t][targetId][inviteType] {
 		if i.ReferenceId() == referenceId {
-			return i
+			return i, nil, ErrAlreadyPending
 		}
 	}
+
+	originated := make([]Model, 0, len(r.inviteIdx[t].byOriginator[originatorId]))
+	for _, i := range r.inviteIdx[t].byOriginator[originatorId] {
+		originated = append(originated, i)
+	}
+	involved := make([]Model, 0)
+	if xp.Exclusive(inviteType) {
+		involved = r.involving(t, inviteType, originatorId, targetId)
+	}
+	es, err := planEvictions(xp, cp, inviteType, involved, r.inviteReg[t][targetId][inviteType], originated)
+	if err != nil {
+		return Model{}, nil, err
+	}
+	for i, e := range es {
+		es[i].invite, err = r.resolve(t, e.Invite(), e.Cause().Status(), at)
+		if err != nil {
+			return Model{}, nil, err
+		}
+	}
+
 	r.inviteReg[t][targetId][inviteType] = append(r.inviteReg[t][targetId][inviteType], m)
-	return m
+	r.inviteIdx[t].add(m)
+	r.expiry.add(m)
+	return m, es, nil
 }
 
-func (r *Registry) GetByOriginator(t tenant.Model, actorId uint32, inviteType string, originatorId uint32) (Model, error) {
-	var tl *sync.RWMutex
-	var ok bool
-	if tl, ok = r.tenantLock[t]; !ok {
-		r.lock.Lock()
-		tl = &sync.RWMutex{}
-		r.inviteReg[t] = make(map[uint32]map[string][]Model)
-		r.tenantLock[t] = tl
-		r.lock.Unlock()
+func (r *InMemoryRegistry) Restore(t tenant.Model, id uint32, originatorId uint32, worldId byte, targetId uint32, inviteType string, referenceId uint32, at time.Time, ttl time.Duration) (Model, error) {
+	tenantLock := r.getTenantLock(t)
+
+	// ids allocated after the restored invite continue from it.
+	r.lock.Lock()
+	if last, ok := r.tenantInviteId[t]; !ok || last < id {
+		r.tenantInviteId[t] = id
 	}
+	r.lock.Unlock()
 
-	tl.RLock()
-	defer tl.RUnlock()
-	var tenReg map[uint32]map[string][]Model
-	if tenReg, ok = r.inviteReg[t]; ok {
-		var charReg map[string][]Model
-		if charReg, ok = tenReg[actorId]; ok {
-			var invReg []Model
-			if invReg, ok = charReg[inviteType]; ok {
-				for _, i := range invReg {
-					if i.OriginatorId() == originatorId {
-						return i, nil
-					}
-				}
+	tenantLock.Lock()
+	defer tenantLock.Unlock()
+	if i, ok := r.inviteIdx[t].byId[id]; ok {
+		return i, ErrAlreadyPending
+	}
+
+	m := Model{
+		tenant:       t,
+		id:           id,
+		inviteType:   inviteType,
+		referenceId:  referenceId,
+		originatorId: originatorId,
+		targetId:     targetId,
+		worldId:      worldId,
+		age:          at,
+		expiresAt:    at.Add(ttl),
+		status:       StatusPending,
+		history:      []Transition{{status: StatusPending, at: at}},
+	}
+	if _, ok := r.inviteReg[t][targetId]; !ok {
+		r.inviteReg[t][targetId] = make(map[string][]Model)
+	}
+	r.inviteReg[t][targetId][inviteType] = append(r.inviteReg[t][targetId][inviteType], m)
+	r.inviteIdx[t].add(m)
+	r.expiry.add(m)
+	return m, nil
+}
+
+// involving returns the pending invites of inviteType sent or received by any of characterIds. The tenant lock must be
+// held.
+func (r *InMemoryRegistry) involving(t tenant.Model, inviteType string, characterIds ...uint32) []Model {
+	results := make(map[uint32]Model)
+	for _, characterId := range characterIds {
+		for _, i := range r.inviteReg[t][characterId][inviteType] {
+			results[i.Id()] = i
+		}
+		for _, i := range r.inviteIdx[t].byOriginator[characterId] {
+			if i.Type() == inviteType {
+				results[i.Id()] = i
 			}
 		}
 	}
-	return Model{}, errors.New("not found")
+	return slices.SortedFunc(maps.Values(results), func(a Model, b Model) int {
+		return cmp.Compare(a.Id(), b.Id())
+	})
 }
 
-func (r *Registry) GetByReference(t tenant.Model, actorId uint32, inviteType string, referenceId uint32) (Model, error) {
-	var tl *sync.RWMutex
-	var ok bool
-	if tl, ok = r.tenantLock[t]; !ok {
-		r.lock.Lock()
-		tl = &sync.RWMutex{}
-		r.inviteReg[t] = make(map[uint32]map[string][]Model)
-		r.tenantLock[t] = tl
-		r.lock.Unlock()
+// resolve moves m from the tenant's pending invites to its resolved invites. The tenant lock must be held.
+func (r *InMemoryRegistry) resolve(t tenant.Model, m Model, status Status, at time.Time) (Model, error) {
+	rm, err := m.Transition(status, at)
+	if err != nil {
+		return m, err
 	}
+	r.unlink(t, m)
+	r.inviteIdx[t].resolved[m.Id()] = rm
+
+	r.retiredLock.Lock()
+	r.retired = append(r.retired, retiredKey{tenant: t, id: m.Id(), at: at})
+	r.retiredLock.Unlock()
+	return rm, nil
+}
 
+func (r *InMemoryRegistry) GetById(t tenant.Model, id uint32) (Model, error) {
+	tl := r.getTenantLock(t)
 	tl.RLock()
 	defer tl.RUnlock()
-	var tenReg map[uint32]map[string][]Model
-	if tenReg, ok = r.inviteReg[t]; ok {
-		var charReg map[string][]Model
-		if charReg, ok = tenReg[actorId]; ok {
-			var invReg []Model
-			if invReg, ok = charReg[inviteType]; ok {
-				for _, i := range invReg {
-					if i.ReferenceId() == referenceId {
-						return i, nil
-					}
-				}
-			}
+	if i, ok := r.inviteIdx[t].byId[id]; ok {
+		return i, nil
+	}
+	if i, ok := r.inviteIdx[t].resolved[id]; ok {
+		return i, nil
+	}
+	return Model{}, ErrNotFound
+}
+
+func (r *InMemoryRegistry) GetByOriginator(t tenant.Model, actorId uint32, inviteType string, originatorId uint32) (Model, error) {
+	tl := r.getTenantLock(t)
+	tl.RLock()
+	defer tl.RUnlock()
+	for _, i := range r.inviteReg[t][actorId][inviteType] {
+		if i.OriginatorId() == originatorId {
+			return i, nil
 		}
 	}
-	return Model{}, errors.New("not found")
+	return Model{}, ErrNotFound
 }
 
-func (r *Registry) GetForCharacter(t tenant.Model, characterId uint32) ([]Model, error) {
-	var tl *sync.RWMutex
-	var ok bool
-	if tl, ok = r.tenantLock[t]; !ok {
-		r.lock.Lock()
-		tl = &sync.RWMutex{}
-		r.inviteReg[t] = make(map[uint32]map[string][]Model)
-		r.tenantLock[t] = tl
-		r.lock.Unlock()
+func (r *InMemoryRegistry) GetByReference(t tenant.Model, actorId uint32, inviteType string, referenceId uint32) (Model, error) {
+	tl := r.getTenantLock(t)
+	tl.RLock()
+	defer tl.RUnlock()
+	for _, i := range r.inviteReg[t][actorId][inviteType] {
+		if i.ReferenceId() == referenceId {
+			return i, nil
+		}
 	}
+	return Model{}, ErrNotFound
+}
 
+func (r *InMemoryRegistry) GetForCharacter(t tenant.Model, characterId uint32) ([]Model, error) {
+	tl := r.getTenantLock(t)
 	tl.RLock()
 	defer tl.RUnlock()
 	var results = make([]Model, 0)
+	for _, v := range r.inviteReg[t][characterId] {
+		results = append(results, v...)
+	}
+	return results, nil
+}
 
-	var tenReg map[uint32]map[string][]Model
-	if tenReg, ok = r.inviteReg[t]; ok {
-		var charReg map[string][]Model
-		if charReg, ok = tenReg[characterId]; ok {
-			for _, v := range charReg {
-				results = append(results, v...)
-			}
-		}
+func (r *InMemoryRegistry) GetForOriginator(t tenant.Model, originatorId uint32) ([]Model, error) {
+	tl := r.getTenantLock(t)
+	tl.RLock()
+	defer tl.RUnlock()
+	var results = make([]Model, 0)
+	for _, i := range r.inviteIdx[t].byOriginator[originatorId] {
+		results = append(results, i)
 	}
 	return results, nil
+}
 
+func (r *InMemoryRegistry) GetForReference(t tenant.Model, inviteType string, referenceId uint32) ([]Model, error) {
+	tl := r.getTenantLock(t)
+	tl.RLock()
+	defer tl.RUnlock()
+	var results = make([]Model, 0)
+	for _, i := range r.inviteIdx[t].byReference[referenceKey{inviteType, referenceId}] {
+		results = append(results, i)
+	}
+	return results, nil
 }
 
-func (r *Registry) Delete(t tenant.Model, actorId uint32, inviteType string, originatorId uint32) error {
-	var tl *sync.RWMutex
-	var ok bool
-	if tl, ok = r.tenantLock[t]; !ok {
-		r.lock.Lock()
-		tl = &sync.RWMutex{}
-		r.inviteReg[t] = make(map[uint32]map[string][]Model)
-		r.tenantLock[t] = tl
-		r.lock.Unlock()
+func (r *InMemoryRegistry) Resolve(t tenant.Model, actorId uint32, inviteType string, originatorId uint32, status Status, at time.Time) (Model, error) {
+	tl := r.getTenantLock(t)
+	tl.Lock()
+	defer tl.Unlock()
+	for _, i := range r.inviteReg[t][actorId][inviteType] {
+		if i.OriginatorId() == originatorId {
+			return r.resolve(t, i, status, at)
+		}
 	}
+	return Model{}, ErrNotFound
+}
 
+func (r *InMemoryRegistry) ResolveById(t tenant.Model, id uint32, status Status, at time.Time) (Model, error) {
+	tl := r.getTenantLock(t)
 	tl.Lock()
 	defer tl.Unlock()
-	var tenReg map[uint32]map[string][]Model
-	if tenReg, ok = r.inviteReg[t]; ok {
-		var charReg map[string][]Model
-		if charReg, ok = tenReg[actorId]; ok {
-			var invReg []Model
-			if invReg, ok = charReg[inviteType]; ok {
-				var found = false
-				var remain = make([]Model, 0)
-				for _, i := range invReg {
-					if i.OriginatorId() != originatorId {
-						remain = append(remain, i)
-					} else {
-						found = true
-					}
-				}
-				r.inviteReg[t][actorId][inviteType] = remain
-				if found {
-					return nil
-				}
+	if i, ok := r.inviteIdx[t].byId[id]; ok {
+		return r.resolve(t, i, status, at)
+	}
+	return Model{}, ErrNotFound
+}
+
+func (r *InMemoryRegistry) GetExpired() ([]Model, error) {
+	return r.expiry.due(time.Now()), nil
+}
+
+// PruneResolved forgets invites resolved before before. Invites are retired in the order they were resolved, so only
+// those being forgotten are visited.
+func (r *InMemoryRegistry) PruneResolved(before time.Time) error {
+	r.retiredLock.Lock()
+	n := 0
+	for n < len(r.retired) && r.retired[n].at.Before(before) {
+		n++
+	}
+	pruned := r.retired[:n]
+	r.retired = slices.Clone(r.retired[n:])
+	r.retiredLock.Unlock()
+
+	for _, k := range pruned {
+		tl := r.getTenantLock(k.tenant)
+		tl.Lock()
+		delete(r.inviteIdx[k.tenant].resolved, k.id)
+		tl.Unlock()
+	}
+	return nil
+}
+
+// CountPending returns the number of pending invites by tenant id and invite type.
+func (r *InMemoryRegistry) CountPending() (map[uuid.UUID]map[string]int, error) {
+	r.lock.Lock()
+	ts := make(map[tenant.Model]*sync.RWMutex, len(r.tenantLock))
+	for t, tl := range r.tenantLock {
+		ts[t] = tl
+	}
+	r.lock.Unlock()
+
+	results := make(map[uuid.UUID]map[string]int)
+	for t, tl := range ts {
+		tl.RLock()
+		for _, m := range r.inviteIdx[t].byId {
+			if _, ok := results[t.Id()]; !ok {
+				results[t.Id()] = make(map[string]int)
 			}
+			results[t.Id()][m.Type()]++
 		}
+		tl.RUnlock()
 	}
-	return errors.New("not found")
+	return results, nil
 }
 
-func (r *Registry) GetExpired(timeout time.Duration) ([]Model, error) {
-	var results = make([]Model, 0)
-	for k, v := range r.inviteReg {
-		if tl, ok := r.tenantLock[k]; ok {
-			tl.RLock()
-			for _, cir := range v {
-				for _, is := range cir {
-					for _, i := range is {
-						if i.Expired(timeout) {
-							results = append(results, i)
-						}
-					}
-				}
-			}
-			tl.RUnlock()
+func (r *InMemoryRegistry) AddCooldown(t tenant.Model, originatorId uint32, targetId uint32, inviteType string, expiresAt time.Time) error {
+	r.cooldownLock.Lock()
+	defer r.cooldownLock.Unlock()
+	r.cooldowns[cooldownKey{t, originatorId, targetId, inviteType}] = Cooldown{
+		tenant:       t,
+		originatorId: originatorId,
+		targetId:     targetId,
+		inviteType:   inviteType,
+		expiresAt:    expiresAt,
+	}
+	return nil
+}
+
+func (r *InMemoryRegistry) GetCooldowns(t tenant.Model, originatorId uint32, targetId uint32) ([]Cooldown, error) {
+	r.cooldownLock.Lock()
+	defer r.cooldownLock.Unlock()
+	now := time.Now()
+	var results = make([]Cooldown, 0)
+	for _, inviteType := range invite2.InviteTypes {
+		if c, ok := r.cooldowns[cooldownKey{t, originatorId, targetId, inviteType}]; ok && c.ExpiresAt().After(now) {
+			results = append(results, c)
 		}
 	}
 	return results, nil
 }
+
+func (r *InMemoryRegistry) DeleteExpiredCooldowns() error {
+	r.cooldownLock.Lock()
+	defer r.cooldownLock.Unlock()
+	now := time.Now()
+	for k, c := range r.cooldowns {
+		if !c.ExpiresAt().After(now) {
+			delete(r.cooldowns, k)
+		}
+	}
+	return nil
+}
+
+// unlink removes the pending invite m from the tenant's registries. The tenant lock must be held.
+func (r *InMemoryRegistry) unlink(t tenant.Model, m Model) {
+	remain := make([]Model, 0)
+	for _, i := range r.inviteReg[t][m.TargetId()][m.Type()] {
+		if i.Id() != m.Id() {
+			remain = append(remain, i)
+		}
+	}
+	r.inviteReg[t][m.TargetId()][m.Type()] = remain
+	r.inviteIdx[t].remove(m)
+	r.expiry.remove(t, m.Id())
+}
+
+// discard forgets the invite m created by a transaction which failed, reinstating the invites it evicted.
+func (r *InMemoryRegistry) discard(t tenant.Model, m Model, es []Eviction) {
+	tl := r.getTenantLock(t)
+	tl.Lock()
+	defer tl.Unlock()
+	r.unlink(t, m)
+	for i := len(es) - 1; i >= 0; i-- {
+		r.reinstate(t, es[i].Invite())
+	}
+}
+
+// reinstate returns rm, resolved by a transaction which failed, to the tenant's pending invites. The tenant lock must
+// be held.
+func (r *InMemoryRegistry) reinstate(t tenant.Model, rm Model) {
+	delete(r.inviteIdx[t].resolved, rm.Id())
+	m := rm
+	m.status = StatusPending
+	m.history = slices.Clone(rm.history[:len(rm.history)-1])
+	if _, ok := r.inviteReg[t][m.TargetId()]; !ok {
+		r.inviteReg[t][m.TargetId()] = make(map[string][]Model)
+	}
+	r.inviteReg[t][m.TargetId()][m.Type()] = append(r.inviteReg[t][m.TargetId()][m.Type()], m)
+	r.inviteIdx[t].add(m)
+	r.expiry.add(m)
+
+	r.retiredLock.Lock()
+	defer r.retiredLock.Unlock()
+	for i := len(r.retired) - 1; i >= 0; i-- {
+		if r.retired[i].tenant == t && r.retired[i].id == rm.Id() {
+			r.retired = slices.Delete(r.retired, i, i+1)
+			break
+		}
+	}
+}
+
+// Transaction runs f against the registry and the configured outbox.Store. Transactions are serialized. When f fails
+// the registry changes it made are undone and the entries it added are discarded, so the registry never holds a change
+// whose events were not added to the outbox.
+func (r *InMemoryRegistry) Transaction(f func(r Registry, s outbox.Store, rc message.Recorder) error) error {
+	r.txLock.Lock()
+	defer r.txLock.Unlock()
+
+	tx := &inMemoryTransaction{InMemoryRegistry: r, s: &bufferedStore{Store: outbox.GetStore()}, rc: &bufferedRecorder{Recorder: transaction.GetStore()}}
+	err := f(tx, tx.s, tx.rc)
+	if err == nil {
+		err = tx.s.commit()
+	}
+	if err == nil {
+		err = tx.rc.commit()
+	}
+	if err != nil {
+		tx.rollback()
+		return err
+	}
+	return nil
+}
+
+// inMemoryTransaction is the Registry given to a function run by InMemoryRegistry.Transaction. It records how to undo
+// each change made through it.
+type inMemoryTransaction struct {
+	*InMemoryRegistry
+	s    *bufferedStore
+	rc   *bufferedRecorder
+	undo []func()
+}
+
+func (tx *inMemoryTransaction) Create(t tenant.Model, originatorId uint32, worldId byte, targetId uint32, inviteType string, referenceId uint32, at time.Time, ttl time.Duration, xp ExclusivityPolicy, cp CapacityPolicy) (Model, []Eviction, error) {
+	m, es, err := tx.InMemoryRegistry.Create(t, originatorId, worldId, targetId, inviteType, referenceId, at, ttl, xp, cp)
+	if err != nil {
+		return m, es, err
+	}
+	tx.undo = append(tx.undo, func() {
+		tx.InMemoryRegistry.discard(t, m, es)
+	})
+	return m, es, nil
+}
+
+func (tx *inMemoryTransaction) Restore(t tenant.Model, id uint32, originatorId uint32, worldId byte, targetId uint32, inviteType string, referenceId uint32, at time.Time, ttl time.Duration) (Model, error) {
+	m, err := tx.InMemoryRegistry.Restore(t, id, originatorId, worldId, targetId, inviteType, referenceId, at, ttl)
+	if err != nil {
+		return m, err
+	}
+	tx.undo = append(tx.undo, func() {
+		tx.InMemoryRegistry.discard(t, m, nil)
+	})
+	return m, nil
+}
+
+func (tx *inMemoryTransaction) Resolve(t tenant.Model, actorId uint32, inviteType string, originatorId uint32, status Status, at time.Time) (Model, error) {
+	m, err := tx.InMemoryRegistry.Resolve(t, actorId, inviteType, originatorId, status, at)
+	if err != nil {
+		return m, err
+	}
+	tx.undo = append(tx.undo, func() {
+		tl := tx.getTenantLock(t)
+		tl.Lock()
+		defer tl.Unlock()
+		tx.reinstate(t, m)
+	})
+	return m, nil
+}
+
+func (tx *inMemoryTransaction) ResolveById(t tenant.Model, id uint32, status Status, at time.Time) (Model, error) {
+	m, err := tx.InMemoryRegistry.ResolveById(t, id, status, at)
+	if err != nil {
+		return m, err
+	}
+	tx.undo = append(tx.undo, func() {
+		tl := tx.getTenantLock(t)
+		tl.Lock()
+		defer tl.Unlock()
+		tx.reinstate(t, m)
+	})
+	return m, nil
+}
+
+func (tx *inMemoryTransaction) AddCooldown(t tenant.Model, originatorId uint32, targetId uint32, inviteType string, expiresAt time.Time) error {
+	k := cooldownKey{t, originatorId, targetId, inviteType}
+	tx.cooldownLock.Lock()
+	prev, existed := tx.cooldowns[k]
+	tx.cooldownLock.Unlock()
+
+	err := tx.InMemoryRegistry.AddCooldown(t, originatorId, targetId, inviteType, expiresAt)
+	if err != nil {
+		return err
+	}
+	tx.undo = append(tx.undo, func() {
+		tx.cooldownLock.Lock()
+		defer tx.cooldownLock.Unlock()
+		if existed {
+			tx.cooldowns[k] = prev
+		} else {
+			delete(tx.cooldowns, k)
+		}
+	})
+	return nil
+}
+
+func (tx *inMemoryTransaction) Transaction(f func(r Registry, s outbox.Store, rc message.Recorder) error) error {
+	return f(tx, tx.s, tx.rc)
+}
+
+// rollback undoes the transaction's changes, most recent first.
+func (tx *inMemoryTransaction) rollback() {
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		tx.undo[i]()
+	}
+	tx.undo = nil
+}
+
+// bufferedStore holds the entries added during an in-memory transaction until the transaction commits.
+type bufferedStore struct {
+	outbox.Store
+	adds []func() error
+}
+
+func (s *bufferedStore) Add(t tenant.Model, token string, headers map[string]string, ms []kafka.Message) error {
+	s.adds = append(s.adds, func() error {
+		return s.Store.Add(t, token, headers, ms)
+	})
+	return nil
+}
+
+func (s *bufferedStore) commit() error {
+	for _, add := range s.adds {
+		err := add()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bufferedRecorder holds the messages recorded during an in-memory transaction until the transaction commits.
+type bufferedRecorder struct {
+	message.Recorder
+	keys []string
+	puts map[string]map[string][]kafka.Message
+}
+
+func (r *bufferedRecorder) Get(key string) (map[string][]kafka.Message, bool, error) {
+	if ms, ok := r.puts[key]; ok {
+		return ms, true, nil
+	}
+	return r.Recorder.Get(key)
+}
+
+func (r *bufferedRecorder) Put(key string, messages map[string][]kafka.Message) error {
+	if r.puts == nil {
+		r.puts = make(map[string]map[string][]kafka.Message)
+	}
+	if _, ok := r.puts[key]; !ok {
+		r.keys = append(r.keys, key)
+	}
+	r.puts[key] = messages
+	return nil
+}
+
+func (r *bufferedRecorder) commit() error {
+	for _, key := range r.keys {
+		err := r.Recorder.Put(key, r.puts[key])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package invite
+
+import (
+	"atlas-invites/kafka/message"
+	"atlas-invites/outbox"
+	"errors"
+	"github.com/segmentio/kafka-go"
+	"testing"
+	"time"
+)
+
+func TestInMemoryTransactionRollback(t *testing.T) {
+	tm := testTenant(t)
+	r := NewInMemoryRegistry()
+	xp := DefaultExclusivityPolicy()
+	cp := DefaultCapacityPolicy()
+
+	a, _, err := r.Create(tm, 1, 0, 2, "BUDDY", 1, time.Now(), time.Minute, xp, cp)
+	if err != nil {
+		t.Fatalf("Unable to create invite: %v", err)
+	}
+	o, _, err := r.Create(tm, 5, 0, 6, "TRADE", 1, time.Now(), time.Minute, xp, cp)
+	if err != nil {
+		t.Fatalf("Unable to create invite: %v", err)
+	}
+
+	failure := errors.New("outbox unavailable")
+	err = r.Transaction(func(tx Registry, s outbox.Store, _ message.Recorder) error {
+		_, err := tx.Resolve(tm, 2, "BUDDY", 1, StatusAccepted, time.Now())
+		if err != nil {
+			return err
+		}
+		// supersedes o, as TRADE is exclusive by default.
+		_, es, err := tx.Create(tm, 6, 0, 7, "TRADE", 2, time.Now(), time.Minute, xp, cp)
+		if err != nil {
+			return err
+		}
+		if len(es) != 1 {
+			t.Fatalf("Expected 1 eviction, got %d.", len(es))
+		}
+		err = tx.AddCooldown(tm, 1, 2, "BUDDY", time.Now().Add(time.Minute))
+		if err != nil {
+			return err
+		}
+		err = s.Add(tm, "EVENT_TOPIC_INVITE_STATUS", nil, []kafka.Message{{Value: []byte("{}")}})
+		if err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expected transaction to fail with [%v], got [%v].", failure, err)
+	}
+
+	for _, i := range []Model{a, o} {
+		m, err := r.GetById(tm, i.Id())
+		if err != nil {
+			t.Fatalf("Unable to get invite [%d]: %v", i.Id(), err)
+		}
+		if !m.Pending() || len(m.History()) != 1 {
+			t.Errorf("Invite [%d] was not reinstated as pending, status [%s].", m.Id(), m.Status())
+		}
+	}
+	is, err := r.GetForCharacter(tm, 7)
+	if err != nil {
+		t.Fatalf("Unable to get invites: %v", err)
+	}
+	if len(is) != 0 {
+		t.Errorf("Invite created in failed transaction remains.")
+	}
+	cs, err := r.GetCooldowns(tm, 1, 2)
+	if err != nil {
+		t.Fatalf("Unable to get cooldowns: %v", err)
+	}
+	if len(cs) != 0 {
+		t.Errorf("Cooldown added in failed transaction remains.")
+	}
+	if r.expiry.h.Len() != 2 {
+		t.Errorf("Expected 2 invites indexed by deadline, got %d.", r.expiry.h.Len())
+	}
+	es, err := outbox.GetStore().Pending(1000)
+	if err != nil {
+		t.Fatalf("Unable to get outbox entries: %v", err)
+	}
+	for _, e := range es {
+		if e.Tenant().Id() == tm.Id() {
+			t.Fatalf("Outbox entries added in failed transaction remain.")
+		}
+	}
+}
+
+func TestResolveByIdLeavesNewerInvite(t *testing.T) {
+	tm := testTenant(t)
+	r := NewInMemoryRegistry()
+	xp := DefaultExclusivityPolicy()
+	cp := DefaultCapacityPolicy()
+
+	a, _, err := r.Create(tm, 1, 0, 2, "BUDDY", 1, time.Now(), -time.Second, xp, cp)
+	if err != nil {
+		t.Fatalf("Unable to create invite: %v", err)
+	}
+	_, err = r.Resolve(tm, 2, "BUDDY", 1, StatusRejected, time.Now())
+	if err != nil {
+		t.Fatalf("Unable to resolve invite: %v", err)
+	}
+	b, _, err := r.Create(tm, 1, 0, 2, "BUDDY", 1, time.Now(), time.Minute, xp, cp)
+	if err != nil {
+		t.Fatalf("Unable to create invite: %v", err)
+	}
+
+	_, err = r.ResolveById(tm, a.Id(), StatusExpired, time.Now())
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected resolved invite to be not found, got %v.", err)
+	}
+	m, err := r.GetById(tm, b.Id())
+	if err != nil {
+		t.Fatalf("Unable to get invite: %v", err)
+	}
+	if !m.Pending() {
+		t.Errorf("Newer invite [%d] was resolved as [%s].", m.Id(), m.Status())
+	}
+
+	m, err = r.ResolveById(tm, b.Id(), StatusExpired, time.Now())
+	if err != nil {
+		t.Fatalf("Unable to resolve invite by id: %v", err)
+	}
+	if m.Status() != StatusExpired {
+		t.Errorf("Expected status [%s], got [%s].", StatusExpired, m.Status())
+	}
+}
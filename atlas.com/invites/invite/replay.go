@@ -0,0 +1,75 @@
+package invite
+
+import (
+	invite2 "atlas-invites/kafka/message/invite"
+	"errors"
+	"github.com/Chronicle20/atlas-tenant"
+	"time"
+)
+
+// Fold applies a previously emitted status event to the registry without emitting anything. It is used to rebuild
+// registry state from the status topic on startup. Events which carry an invite id restore and resolve the invite under
+// that id, and rejections rebuild the re-invite cooldown they started.
+func Fold(r Registry, ep ExpirationPolicy, rp ReinvitePolicy) func(t tenant.Model) func(eventType string, inviteId uint32, worldId byte, inviteType string, referenceId uint32, originatorId uint32, targetId uint32, reason string, at time.Time) error {
+	return func(t tenant.Model) func(eventType string, inviteId uint32, worldId byte, inviteType string, referenceId uint32, originatorId uint32, targetId uint32, reason string, at time.Time) error {
+		return func(eventType string, inviteId uint32, worldId byte, inviteType string, referenceId uint32, originatorId uint32, targetId uint32, reason string, at time.Time) error {
+			if at.IsZero() {
+				at = time.Now()
+			}
+			switch eventType {
+			case invite2.EventInviteStatusTypeCreated:
+				// the invite keeps its original age, so one whose time to live elapsed while the service was down is
+				// expired by the next timeout task run.
+				var err error
+				if inviteId != 0 {
+					_, err = r.Restore(t, inviteId, originatorId, worldId, targetId, inviteType, referenceId, at, ep.Ttl(t, inviteType))
+				} else {
+					_, _, err = r.Create(t, originatorId, worldId, targetId, inviteType, referenceId, at, ep.Ttl(t, inviteType), ExclusivityPolicy{}, CapacityPolicy{})
+				}
+				if errors.Is(err, ErrAlreadyPending) {
+					return nil
+				}
+				return err
+			case invite2.EventInviteStatusTypeAccepted, invite2.EventInviteStatusTypeRejected, invite2.EventInviteStatusTypeCancelled, invite2.EventInviteStatusTypeExpired:
+				// an invite declined by preference was never created.
+				if eventType == invite2.EventInviteStatusTypeRejected && reason == invite2.RejectReasonPreferenceDeclined {
+					return nil
+				}
+				var err error
+				if inviteId != 0 {
+					_, err = r.ResolveById(t, inviteId, resolvedStatus(eventType, reason), at)
+				} else {
+					_, err = r.Resolve(t, targetId, inviteType, originatorId, resolvedStatus(eventType, reason), at)
+				}
+				// the invite may have been created before the replay window began.
+				if err != nil && !errors.Is(err, ErrNotFound) {
+					return err
+				}
+				if eventType == invite2.EventInviteStatusTypeRejected {
+					// a cooldown which ran out while the service was down is not restored.
+					if d := rp.Cooldown(inviteType); d > 0 && at.Add(d).After(time.Now()) {
+						return r.AddCooldown(t, originatorId, targetId, inviteType, at.Add(d))
+					}
+				}
+				return nil
+			}
+			return nil
+		}
+	}
+}
+
+// resolvedStatus returns the status an invite resolved by a status event of eventType with reason entered.
+func resolvedStatus(eventType string, reason string) Status {
+	switch eventType {
+	case invite2.EventInviteStatusTypeAccepted:
+		return StatusAccepted
+	case invite2.EventInviteStatusTypeRejected:
+		return StatusRejected
+	case invite2.EventInviteStatusTypeExpired:
+		return StatusExpired
+	}
+	if reason == invite2.CancelReasonSuperseded {
+		return StatusSuperseded
+	}
+	return StatusCancelled
+}
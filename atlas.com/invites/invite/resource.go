@@ -0,0 +1,216 @@
+package invite
+
+import (
+	"atlas-invites/audit"
+	invite2 "atlas-invites/kafka/message/invite"
+	"atlas-invites/rest"
+	"errors"
+	"github.com/Chronicle20/atlas-model/model"
+	"github.com/Chronicle20/atlas-rest/server"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jtumidanski/api2go/jsonapi"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+)
+
+const (
+	GetInvite    = "get_invite"
+	GetInvites   = "get_invites"
+	CreateInvite = "create_invite"
+	AcceptInvite = "accept_invite"
+	RejectInvite = "reject_invite"
+	CancelInvite = "cancel_invite"
+)
+
+func InitResource(si jsonapi.ServerInformation) server.RouteInitializer {
+	return func(router *mux.Router, l logrus.FieldLogger) {
+		registerHandler := rest.RegisterHandler(l)(si)
+		registerInputHandler := rest.RegisterInputHandler[RestModel](l)(si)
+		r := router.PathPrefix("/invites").Subrouter()
+		r.HandleFunc("", registerHandler(GetInvites, handleGetInvites)).Queries("type", "{type}", "referenceId", "{referenceId}").Methods(http.MethodGet)
+		r.HandleFunc("", registerInputHandler(CreateInvite, handleCreateInvite)).Methods(http.MethodPost)
+		r.HandleFunc("/{inviteId}", registerHandler(GetInvite, handleGetInvite)).Methods(http.MethodGet)
+		r.HandleFunc("/{inviteId}/accept", registerHandler(AcceptInvite, handleAcceptInvite)).Methods(http.MethodPost)
+		r.HandleFunc("/{inviteId}/reject", registerHandler(RejectInvite, handleRejectInvite)).Methods(http.MethodPost)
+		r.HandleFunc("/{inviteId}", registerHandler(CancelInvite, handleCancelInvite)).Methods(http.MethodDelete)
+	}
+}
+
+func handleGetInvite(d *rest.HandlerDependency, c *rest.HandlerContext) http.HandlerFunc {
+	return rest.ParseInviteId(d.Logger(), func(inviteId uint32) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			res, err := model.Map(Transform)(NewProcessor(d.Logger(), d.Context()).ByIdProvider(inviteId))()
+			if err != nil {
+				w.WriteHeader(statusForError(err))
+				return
+			}
+
+			query := r.URL.Query()
+			queryParams := jsonapi.ParseQueryFields(&query)
+			server.MarshalResponse[RestModel](d.Logger())(w)(c.ServerInformation())(queryParams)(res)
+		}
+	})
+}
+
+func handleGetInvites(d *rest.HandlerDependency, c *rest.HandlerContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inviteType := mux.Vars(r)["type"]
+		referenceId, err := strconv.Atoi(mux.Vars(r)["referenceId"])
+		if err != nil {
+			d.Logger().WithError(err).Errorf("Unable to properly parse referenceId from query.")
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		res, err := model.SliceMap(Transform)(NewProcessor(d.Logger(), d.Context()).ByReferenceProvider(inviteType, uint32(referenceId)))()()
+		if err != nil {
+			d.Logger().WithError(err).Errorf("Creating REST model.")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		query := r.URL.Query()
+		queryParams := jsonapi.ParseQueryFields(&query)
+		server.MarshalResponse[[]RestModel](d.Logger())(w)(c.ServerInformation())(queryParams)(res)
+	}
+}
+
+func handleCreateInvite(d *rest.HandlerDependency, c *rest.HandlerContext, input RestModel) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		im, err := Extract(input)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		p := NewProcessor(d.Logger(), d.Context())
+		transactionId := uuid.New()
+		received(d, invite2.CommandInviteTypeCreate, im, transactionId)
+		i, err := p.CreateAndEmit(im.ReferenceId(), im.WorldId(), im.Type(), im.OriginatorId(), im.TargetId(), transactionId)
+		if errors.Is(err, ErrPreferenceDeclined) {
+			_ = p.ErrorAndEmit(im.ReferenceId(), im.WorldId(), im.Type(), invite2.CommandInviteTypeCreate, im.OriginatorId(), im.TargetId(), transactionId, err)
+		} else if err != nil {
+			refused(d, invite2.CommandInviteTypeCreate, im, transactionId, err)
+		}
+		if err != nil {
+			w.WriteHeader(statusForError(err))
+			return
+		}
+
+		res, err := model.Map(Transform)(model.FixedProvider(i))()
+		if err != nil {
+			d.Logger().WithError(err).Errorf("Creating REST model.")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		query := r.URL.Query()
+		queryParams := jsonapi.ParseQueryFields(&query)
+		server.MarshalResponse[RestModel](d.Logger())(w)(c.ServerInformation())(queryParams)(res)
+	}
+}
+
+func handleAcceptInvite(d *rest.HandlerDependency, _ *rest.HandlerContext) http.HandlerFunc {
+	return rest.ParseInviteId(d.Logger(), func(inviteId uint32) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			p := NewProcessor(d.Logger(), d.Context())
+			i, err := p.GetById(inviteId)
+			if err == nil {
+				err = act(d, invite2.CommandInviteTypeAccept, i, func(transactionId uuid.UUID) error {
+					_, err := p.AcceptAndEmit(i.ReferenceId(), i.WorldId(), i.Type(), i.TargetId(), transactionId)
+					return err
+				})
+			}
+			if err != nil {
+				w.WriteHeader(statusForError(err))
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+}
+
+func handleRejectInvite(d *rest.HandlerDependency, _ *rest.HandlerContext) http.HandlerFunc {
+	return rest.ParseInviteId(d.Logger(), func(inviteId uint32) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			p := NewProcessor(d.Logger(), d.Context())
+			i, err := p.GetById(inviteId)
+			if err == nil {
+				err = act(d, invite2.CommandInviteTypeReject, i, func(transactionId uuid.UUID) error {
+					_, err := p.RejectAndEmit(i.OriginatorId(), i.WorldId(), i.Type(), i.TargetId(), transactionId)
+					return err
+				})
+			}
+			if err != nil {
+				w.WriteHeader(statusForError(err))
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+}
+
+func handleCancelInvite(d *rest.HandlerDependency, _ *rest.HandlerContext) http.HandlerFunc {
+	return rest.ParseInviteId(d.Logger(), func(inviteId uint32) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			p := NewProcessor(d.Logger(), d.Context())
+			i, err := p.GetById(inviteId)
+			if err == nil {
+				err = act(d, invite2.CommandInviteTypeCancel, i, func(transactionId uuid.UUID) error {
+					_, err := p.CancelAndEmit(i.ReferenceId(), i.WorldId(), i.Type(), i.OriginatorId(), i.TargetId(), transactionId)
+					return err
+				})
+			}
+			if err != nil {
+				w.WriteHeader(statusForError(err))
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+}
+
+// act issues a command of commandType against i, recording the command in the audit log along with the reason it was
+// refused, if it was.
+func act(d *rest.HandlerDependency, commandType string, i Model, f func(transactionId uuid.UUID) error) error {
+	transactionId := uuid.New()
+	received(d, commandType, i, transactionId)
+	err := ErrInvalidTransition
+	if i.Pending() {
+		err = f(transactionId)
+	}
+	if err != nil {
+		refused(d, commandType, i, transactionId, err)
+	}
+	return err
+}
+
+// received records a command issued over REST in the audit log before it is acted upon.
+func received(d *rest.HandlerDependency, commandType string, i Model, transactionId uuid.UUID) {
+	ap := audit.NewProcessor(d.Logger(), d.Context())
+	_ = ap.Record(ap.Command(commandType, i.WorldId(), i.Type(), i.ReferenceId(), i.OriginatorId(), i.TargetId(), CommandActor(commandType, i.OriginatorId(), i.TargetId()), transactionId))
+}
+
+// refused records the reason a command issued over REST was refused in the audit log.
+func refused(d *rest.HandlerDependency, commandType string, i Model, transactionId uuid.UUID, err error) {
+	ap := audit.NewProcessor(d.Logger(), d.Context())
+	_ = ap.Record(ap.Refusal(commandType, i.WorldId(), i.Type(), i.ReferenceId(), i.OriginatorId(), i.TargetId(), CommandActor(commandType, i.OriginatorId(), i.TargetId()), Reason(err), transactionId))
+}
+
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrAlreadyPending), errors.Is(err, ErrCapacityExceeded), errors.Is(err, ErrExclusivityConflict), errors.Is(err, ErrInvalidTransition):
+		return http.StatusConflict
+	case errors.Is(err, ErrSelfInvite), errors.Is(err, ErrInvalidType):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrBlocked), errors.Is(err, ErrPreferenceDeclined):
+		return http.StatusForbidden
+	case errors.Is(err, ErrRateLimited), errors.Is(err, ErrCooldown):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
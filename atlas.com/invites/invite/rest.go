@@ -1,17 +1,27 @@
 package invite
 
 import (
+	"math"
 	"strconv"
 	"time"
 )
 
 type RestModel struct {
-	Id           uint32    `json:"-"`
-	Type         string    `json:"type"`
-	ReferenceId  uint32    `json:"referenceId"`
-	OriginatorId uint32    `json:"originatorId"`
-	TargetId     uint32    `json:"targetId"`
-	Age          time.Time `json:"age"`
+	Id           uint32                `json:"-"`
+	Type         string                `json:"type"`
+	ReferenceId  uint32                `json:"referenceId"`
+	OriginatorId uint32                `json:"originatorId"`
+	TargetId     uint32                `json:"targetId"`
+	WorldId      byte                  `json:"worldId"`
+	Age          time.Time             `json:"age"`
+	ExpiresAt    time.Time             `json:"expiresAt"`
+	Status       string                `json:"status"`
+	History      []TransitionRestModel `json:"history"`
+}
+
+type TransitionRestModel struct {
+	Status string    `json:"status"`
+	At     time.Time `json:"at"`
 }
 
 func (r RestModel) GetName() string {
@@ -32,12 +42,66 @@ func (r *RestModel) SetID(strId string) error {
 }
 
 func Transform(m Model) (RestModel, error) {
+	history := make([]TransitionRestModel, 0, len(m.history))
+	for _, t := range m.history {
+		history = append(history, TransitionRestModel{Status: string(t.status), At: t.at})
+	}
 	return RestModel{
 		Id:           m.id,
 		Type:         m.inviteType,
 		ReferenceId:  m.referenceId,
 		OriginatorId: m.originatorId,
 		TargetId:     m.targetId,
+		WorldId:      m.worldId,
 		Age:          m.age,
+		ExpiresAt:    m.expiresAt,
+		Status:       string(m.status),
+		History:      history,
+	}, nil
+}
+
+func Extract(r RestModel) (Model, error) {
+	return Model{
+		id:           r.Id,
+		inviteType:   r.Type,
+		referenceId:  r.ReferenceId,
+		originatorId: r.OriginatorId,
+		targetId:     r.TargetId,
+		worldId:      r.WorldId,
+		age:          r.Age,
+		expiresAt:    r.ExpiresAt,
+	}, nil
+}
+
+type CooldownRestModel struct {
+	Id               string    `json:"-"`
+	Type             string    `json:"type"`
+	OriginatorId     uint32    `json:"originatorId"`
+	TargetId         uint32    `json:"targetId"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+	RemainingSeconds int64     `json:"remainingSeconds"`
+}
+
+func (r CooldownRestModel) GetName() string {
+	return "invite-cooldowns"
+}
+
+func (r CooldownRestModel) GetID() string {
+	return r.Id
+}
+
+func (r *CooldownRestModel) SetID(strId string) error {
+	r.Id = strId
+	return nil
+}
+
+func TransformCooldown(c Cooldown) (CooldownRestModel, error) {
+	return CooldownRestModel{
+		Id:               c.inviteType,
+		Type:             c.inviteType,
+		OriginatorId:     c.originatorId,
+		TargetId:         c.targetId,
+		ExpiresAt:        c.expiresAt,
+		RemainingSeconds: int64(math.Ceil(c.Remaining().Seconds())),
 	}, nil
 }
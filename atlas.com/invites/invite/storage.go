@@ -0,0 +1,238 @@
+package invite
+
+import (
+	"atlas-invites/database"
+	"atlas-invites/kafka/message"
+	"atlas-invites/outbox"
+	"atlas-invites/transaction"
+	"errors"
+	"github.com/Chronicle20/atlas-model/model"
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// DatabaseRegistry is a Registry backed by a relational database so pending invites survive restarts.
+type DatabaseRegistry struct {
+	db *gorm.DB
+}
+
+func NewDatabaseRegistry(db *gorm.DB) *DatabaseRegistry {
+	return &DatabaseRegistry{db: db}
+}
+
+func (r *DatabaseRegistry) Create(t tenant.Model, originatorId uint32, worldId byte, targetId uint32, inviteType string, referenceId uint32, at time.Time, ttl time.Duration, xp ExclusivityPolicy, cp CapacityPolicy) (Model, []Eviction, error) {
+	var m Model
+	var es []Eviction
+	err := database.ExecuteTransaction(r.db, func(tx *gorm.DB) error {
+		// creation is serialized across instances so capacity and exclusivity are checked against the invites of the
+		// tenant created before it.
+		err := lockTenant(tx, t)
+		if err != nil {
+			return err
+		}
+
+		e, err := getByReference(t.Id())(targetId)(inviteType)(referenceId)(tx)()
+		if err == nil {
+			m, err = Make(e)
+			if err != nil {
+				return err
+			}
+			return ErrAlreadyPending
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		targeted, err := model.SliceMap(Make)(getForTarget(t.Id())(targetId)(inviteType)(tx))()()
+		if err != nil {
+			return err
+		}
+		originated, err := model.SliceMap(Make)(getForOriginator(t.Id())(originatorId)(tx))()()
+		if err != nil {
+			return err
+		}
+		involved := make([]Model, 0)
+		if xp.Exclusive(inviteType) {
+			involved, err = model.SliceMap(Make)(getInvolving(t.Id())(inviteType)(originatorId, targetId)(tx))()()
+			if err != nil {
+				return err
+			}
+		}
+		es, err = planEvictions(xp, cp, inviteType, involved, targeted, originated)
+		if err != nil {
+			return err
+		}
+		for i, ev := range es {
+			es[i].invite, err = resolveInvite(tx, ev.Invite(), ev.Cause().Status(), at)
+			if err != nil {
+				return err
+			}
+		}
+
+		id, err := nextId(tx, t)
+		if err != nil {
+			return err
+		}
+		e, err = create(tx, t, id, originatorId, worldId, targetId, inviteType, referenceId, at, at.Add(ttl))
+		if err != nil {
+			return err
+		}
+		m, err = Make(e)
+		return err
+	})
+	if err != nil {
+		return m, nil, err
+	}
+	return m, es, nil
+}
+
+func (r *DatabaseRegistry) Restore(t tenant.Model, id uint32, originatorId uint32, worldId byte, targetId uint32, inviteType string, referenceId uint32, at time.Time, ttl time.Duration) (Model, error) {
+	var m Model
+	err := database.ExecuteTransaction(r.db, func(tx *gorm.DB) error {
+		i, err := model.Map(Make)(getById(t.Id())(id)(tx))()
+		if err == nil {
+			m = i
+			return ErrAlreadyPending
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		err = advanceId(tx, t, id)
+		if err != nil {
+			return err
+		}
+		e, err := create(tx, t, id, originatorId, worldId, targetId, inviteType, referenceId, at, at.Add(ttl))
+		if err != nil {
+			return err
+		}
+		m, err = Make(e)
+		return err
+	})
+	return m, err
+}
+
+func (r *DatabaseRegistry) GetById(t tenant.Model, id uint32) (Model, error) {
+	m, err := model.Map(Make)(getById(t.Id())(id)(r.db))()
+	return m, translateError(err)
+}
+
+func (r *DatabaseRegistry) GetByOriginator(t tenant.Model, actorId uint32, inviteType string, originatorId uint32) (Model, error) {
+	m, err := model.Map(Make)(getByOriginator(t.Id())(actorId)(inviteType)(originatorId)(r.db))()
+	return m, translateError(err)
+}
+
+func (r *DatabaseRegistry) GetByReference(t tenant.Model, actorId uint32, inviteType string, referenceId uint32) (Model, error) {
+	m, err := model.Map(Make)(getByReference(t.Id())(actorId)(inviteType)(referenceId)(r.db))()
+	return m, translateError(err)
+}
+
+func (r *DatabaseRegistry) GetForCharacter(t tenant.Model, characterId uint32) ([]Model, error) {
+	return model.SliceMap(Make)(getForCharacter(t.Id())(characterId)(r.db))()()
+}
+
+func (r *DatabaseRegistry) GetForOriginator(t tenant.Model, originatorId uint32) ([]Model, error) {
+	return model.SliceMap(Make)(getForOriginator(t.Id())(originatorId)(r.db))()()
+}
+
+func (r *DatabaseRegistry) GetForReference(t tenant.Model, inviteType string, referenceId uint32) ([]Model, error) {
+	return model.SliceMap(Make)(getForReference(t.Id())(inviteType)(referenceId)(r.db))()()
+}
+
+func (r *DatabaseRegistry) Resolve(t tenant.Model, actorId uint32, inviteType string, originatorId uint32, status Status, at time.Time) (Model, error) {
+	var m Model
+	err := database.ExecuteTransaction(r.db, func(tx *gorm.DB) error {
+		i, err := model.Map(Make)(getByOriginator(t.Id())(actorId)(inviteType)(originatorId)(tx))()
+		if err != nil {
+			return translateError(err)
+		}
+		m, err = resolveInvite(tx, i, status, at)
+		return err
+	})
+	return m, err
+}
+
+func (r *DatabaseRegistry) ResolveById(t tenant.Model, id uint32, status Status, at time.Time) (Model, error) {
+	var m Model
+	err := database.ExecuteTransaction(r.db, func(tx *gorm.DB) error {
+		i, err := model.Map(Make)(getById(t.Id())(id)(tx))()
+		if err != nil {
+			return translateError(err)
+		}
+		if i.Status() != StatusPending {
+			return ErrNotFound
+		}
+		m, err = resolveInvite(tx, i, status, at)
+		return err
+	})
+	return m, err
+}
+
+// resolveInvite moves m to status at, reporting ErrNotFound when it has already been resolved.
+func resolveInvite(db *gorm.DB, m Model, status Status, at time.Time) (Model, error) {
+	rm, err := m.Transition(status, at)
+	if err != nil {
+		return m, err
+	}
+	count, err := resolve(db, m.Tenant(), m.Id(), status, at)
+	if err != nil {
+		return m, err
+	}
+	if count == 0 {
+		return m, ErrNotFound
+	}
+	return rm, nil
+}
+
+func (r *DatabaseRegistry) GetExpired() ([]Model, error) {
+	return model.SliceMap(Make)(getExpiredAt(time.Now())(r.db))()()
+}
+
+func (r *DatabaseRegistry) PruneResolved(before time.Time) error {
+	return deleteResolvedBefore(r.db, before)
+}
+
+func (r *DatabaseRegistry) CountPending() (map[uuid.UUID]map[string]int, error) {
+	cs, err := countPending(r.db)
+	if err != nil {
+		return nil, err
+	}
+	results := make(map[uuid.UUID]map[string]int)
+	for _, c := range cs {
+		if _, ok := results[c.TenantId]; !ok {
+			results[c.TenantId] = make(map[string]int)
+		}
+		results[c.TenantId][c.InviteType] = c.Count
+	}
+	return results, nil
+}
+
+func (r *DatabaseRegistry) AddCooldown(t tenant.Model, originatorId uint32, targetId uint32, inviteType string, expiresAt time.Time) error {
+	return putCooldown(r.db, t, originatorId, targetId, inviteType, expiresAt)
+}
+
+func (r *DatabaseRegistry) GetCooldowns(t tenant.Model, originatorId uint32, targetId uint32) ([]Cooldown, error) {
+	return model.SliceMap(MakeCooldown)(getCooldownsActiveAt(t.Id())(originatorId)(targetId)(time.Now())(r.db))()()
+}
+
+func (r *DatabaseRegistry) DeleteExpiredCooldowns() error {
+	return deleteCooldownsExpiredAt(r.db, time.Now())
+}
+
+// Transaction runs f within a database transaction. The Registry, outbox.Store and message.Recorder given to f write
+// through that transaction, so invite changes, the messages describing them and the record of the command which caused
+// them are committed or rolled back together. Invites created within it hold their tenant's lock until it ends.
+func (r *DatabaseRegistry) Transaction(f func(r Registry, s outbox.Store, rc message.Recorder) error) error {
+	return database.ExecuteTransaction(r.db, func(tx *gorm.DB) error {
+		return f(NewDatabaseRegistry(tx), outbox.NewDatabaseStore(tx), transaction.Bind(transaction.GetStore(), tx))
+	})
+}
+
+func translateError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
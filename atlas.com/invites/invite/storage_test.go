@@ -0,0 +1,234 @@
+package invite
+
+import (
+	"errors"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testDatabase connects to the database named by TEST_DATABASE_DSN, skipping the test when it is unset.
+func testDatabase(t *testing.T) *gorm.DB {
+	dsn, ok := os.LookupEnv("TEST_DATABASE_DSN")
+	if !ok || dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set.")
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: dsn, PreferSimpleProtocol: true}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("Unable to connect to database: %v", err)
+	}
+	err = Migration(db)
+	if err != nil {
+		t.Fatalf("Unable to migrate database: %v", err)
+	}
+	return db
+}
+
+func TestDatabaseRegistryCreate(t *testing.T) {
+	tm := testTenant(t)
+	r := NewDatabaseRegistry(testDatabase(t))
+	xp := DefaultExclusivityPolicy()
+	cp := DefaultCapacityPolicy()
+
+	a, es, err := r.Create(tm, 1, 0, 2, "BUDDY", 1, time.Now(), time.Minute, xp, cp)
+	if err != nil {
+		t.Fatalf("Unable to create invite: %v", err)
+	}
+	if a.Id() != StartInviteId {
+		t.Errorf("Expected first invite id [%d], got [%d].", StartInviteId, a.Id())
+	}
+	if len(es) != 0 {
+		t.Errorf("Expected no evictions, got %d.", len(es))
+	}
+
+	m, err := r.GetById(tm, a.Id())
+	if err != nil {
+		t.Fatalf("Unable to get invite: %v", err)
+	}
+	if m.OriginatorId() != 1 || m.TargetId() != 2 || m.Status() != StatusPending {
+		t.Errorf("Stored invite [%d] does not match the one created.", m.Id())
+	}
+
+	_, _, err = r.Create(tm, 1, 0, 2, "BUDDY", 1, time.Now(), time.Minute, xp, cp)
+	if !errors.Is(err, ErrAlreadyPending) {
+		t.Errorf("Expected duplicate invite to be refused, got %v.", err)
+	}
+}
+
+func TestDatabaseRegistryIdsNotReusedAfterPrune(t *testing.T) {
+	tm := testTenant(t)
+	r := NewDatabaseRegistry(testDatabase(t))
+	xp := DefaultExclusivityPolicy()
+	cp := DefaultCapacityPolicy()
+
+	a, _, err := r.Create(tm, 1, 0, 2, "BUDDY", 1, time.Now(), time.Minute, xp, cp)
+	if err != nil {
+		t.Fatalf("Unable to create invite: %v", err)
+	}
+	_, err = r.Resolve(tm, 2, "BUDDY", 1, StatusAccepted, time.Now())
+	if err != nil {
+		t.Fatalf("Unable to resolve invite: %v", err)
+	}
+	err = r.PruneResolved(time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Unable to prune resolved invites: %v", err)
+	}
+
+	b, _, err := r.Create(tm, 1, 0, 2, "BUDDY", 1, time.Now(), time.Minute, xp, cp)
+	if err != nil {
+		t.Fatalf("Unable to create invite: %v", err)
+	}
+	if b.Id() <= a.Id() {
+		t.Errorf("Invite id [%d] reused after pruning.", b.Id())
+	}
+}
+
+func TestDatabaseRegistryConcurrentIds(t *testing.T) {
+	tm := testTenant(t)
+	db := testDatabase(t)
+	xp := DefaultExclusivityPolicy()
+	cp := DefaultCapacityPolicy()
+
+	// each registry stands in for a separate instance sharing the database.
+	const count = 20
+	ids := make(chan uint32, count)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m, _, err := NewDatabaseRegistry(db).Create(tm, uint32(i+1), 0, uint32(i+100), "BUDDY", uint32(i+1), time.Now(), time.Minute, xp, cp)
+			if err != nil {
+				t.Errorf("Unable to create invite: %v", err)
+				return
+			}
+			ids <- m.Id()
+		}(i)
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[uint32]bool)
+	for id := range ids {
+		if seen[id] {
+			t.Errorf("Invite id [%d] allocated more than once.", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestDatabaseRegistryConcurrentCapacity(t *testing.T) {
+	tm := testTenant(t)
+	db := testDatabase(t)
+	xp := DefaultExclusivityPolicy()
+	cp := CapacityPolicy{target: Limit{max: 1, overflow: OverflowReject}, originator: Limit{overflow: OverflowReject}}
+
+	// each registry stands in for a separate instance sharing the database, so only the advisory lock orders them.
+	const count = 10
+	var created atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _, err := NewDatabaseRegistry(db).Create(tm, uint32(i+1), 0, 100, "BUDDY", uint32(i+1), time.Now(), time.Minute, xp, cp)
+			if err == nil {
+				created.Add(1)
+				return
+			}
+			if !errors.Is(err, ErrCapacityExceeded) {
+				t.Errorf("Expected [%v], got [%v].", ErrCapacityExceeded, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if n := created.Load(); n != 1 {
+		t.Errorf("Expected 1 invite to be created within capacity, got %d.", n)
+	}
+}
+
+func TestDatabaseRegistryResolve(t *testing.T) {
+	tm := testTenant(t)
+	r := NewDatabaseRegistry(testDatabase(t))
+	xp := DefaultExclusivityPolicy()
+	cp := DefaultCapacityPolicy()
+
+	a, _, err := r.Create(tm, 1, 0, 2, "BUDDY", 1, time.Now(), time.Minute, xp, cp)
+	if err != nil {
+		t.Fatalf("Unable to create invite: %v", err)
+	}
+	at := time.Now().Truncate(time.Microsecond)
+	m, err := r.Resolve(tm, 2, "BUDDY", 1, StatusRejected, at)
+	if err != nil {
+		t.Fatalf("Unable to resolve invite: %v", err)
+	}
+	if m.Status() != StatusRejected {
+		t.Errorf("Expected status [%s], got [%s].", StatusRejected, m.Status())
+	}
+
+	_, err = r.Resolve(tm, 2, "BUDDY", 1, StatusAccepted, time.Now())
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected resolved invite to be not found, got %v.", err)
+	}
+
+	m, err = r.GetById(tm, a.Id())
+	if err != nil {
+		t.Fatalf("Unable to get invite: %v", err)
+	}
+	if m.Status() != StatusRejected {
+		t.Errorf("Expected stored status [%s], got [%s].", StatusRejected, m.Status())
+	}
+	ps, err := r.GetForCharacter(tm, 2)
+	if err != nil {
+		t.Fatalf("Unable to get invites: %v", err)
+	}
+	if len(ps) != 0 {
+		t.Errorf("Expected no pending invites, got %d.", len(ps))
+	}
+}
+
+func TestDatabaseRegistryGetExpired(t *testing.T) {
+	tm := testTenant(t)
+	r := NewDatabaseRegistry(testDatabase(t))
+	xp := DefaultExclusivityPolicy()
+	cp := DefaultCapacityPolicy()
+
+	for _, targetId := range []uint32{2, 3, 4} {
+		_, _, err := r.Create(tm, 1, 0, targetId, "BUDDY", targetId, time.Now(), -time.Second, xp, cp)
+		if err != nil {
+			t.Fatalf("Unable to create invite: %v", err)
+		}
+	}
+	_, _, err := r.Create(tm, 1, 0, 5, "BUDDY", 5, time.Now(), time.Hour, xp, cp)
+	if err != nil {
+		t.Fatalf("Unable to create invite: %v", err)
+	}
+	_, err = r.Resolve(tm, 3, "BUDDY", 1, StatusAccepted, time.Now())
+	if err != nil {
+		t.Fatalf("Unable to resolve invite: %v", err)
+	}
+
+	is, err := r.GetExpired()
+	if err != nil {
+		t.Fatalf("Unable to get expired invites: %v", err)
+	}
+	var count int
+	for _, i := range is {
+		if i.Tenant().Id() != tm.Id() {
+			continue
+		}
+		count++
+		if i.TargetId() == 3 || i.TargetId() == 5 {
+			t.Errorf("Invite [%d] for [%d] reported as expired.", i.Id(), i.TargetId())
+		}
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 expired invites, got %d.", count)
+	}
+}
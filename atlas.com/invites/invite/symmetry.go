@@ -0,0 +1,75 @@
+package invite
+
+import (
+	invite2 "atlas-invites/kafka/message/invite"
+	"os"
+	"strings"
+	"sync"
+)
+
+// SymmetryPolicy resolves which invite types are symmetric. When a character sends an invite of a symmetric type to a
+// character which already has one pending to them, the pending invite is accepted instead.
+type SymmetryPolicy struct {
+	types map[string]bool
+}
+
+func NewSymmetryPolicy(inviteTypes ...string) SymmetryPolicy {
+	p := SymmetryPolicy{types: make(map[string]bool)}
+	for _, inviteType := range inviteTypes {
+		p.types[inviteType] = true
+	}
+	return p
+}
+
+// DefaultSymmetryPolicy makes BUDDY and FAMILY invites symmetric.
+func DefaultSymmetryPolicy() SymmetryPolicy {
+	return NewSymmetryPolicy(invite2.InviteTypeBuddy, invite2.InviteTypeFamily)
+}
+
+func (p SymmetryPolicy) Symmetric(inviteType string) bool {
+	return p.types[inviteType]
+}
+
+// SymmetryPolicyFromEnv reads INVITE_SYMMETRIC_TYPES, a comma separated list of symmetric invite types. When it is
+// unset DefaultSymmetryPolicy applies, while an empty list makes no invite type symmetric.
+func SymmetryPolicyFromEnv() (SymmetryPolicy, error) {
+	val, ok := os.LookupEnv("INVITE_SYMMETRIC_TYPES")
+	if !ok {
+		return DefaultSymmetryPolicy(), nil
+	}
+
+	inviteTypes := make([]string, 0)
+	for _, inviteType := range strings.Split(val, ",") {
+		inviteType = strings.TrimSpace(inviteType)
+		if inviteType == "" {
+			continue
+		}
+		if !validType(inviteType) {
+			return SymmetryPolicy{}, ErrInvalidType
+		}
+		inviteTypes = append(inviteTypes, inviteType)
+	}
+	return NewSymmetryPolicy(inviteTypes...), nil
+}
+
+var symmetryPolicy SymmetryPolicy
+var symmetryOnce sync.Once
+
+// InitSymmetryPolicy sets the SymmetryPolicy used by the service, and panics if one is already set.
+func InitSymmetryPolicy(p SymmetryPolicy) {
+	set := false
+	symmetryOnce.Do(func() {
+		symmetryPolicy = p
+		set = true
+	})
+	if !set {
+		panic("invite symmetry policy already set")
+	}
+}
+
+func GetSymmetryPolicy() SymmetryPolicy {
+	symmetryOnce.Do(func() {
+		symmetryPolicy = DefaultSymmetryPolicy()
+	})
+	return symmetryPolicy
+}
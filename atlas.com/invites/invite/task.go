@@ -1,56 +1,102 @@
 package invite
 
 import (
+	"atlas-invites/audit"
+	"atlas-invites/kafka/message"
 	invite2 "atlas-invites/kafka/message/invite"
-	"atlas-invites/kafka/producer"
+	"atlas-invites/metrics"
+	"atlas-invites/outbox"
+	"atlas-invites/transaction"
 	"context"
+	"errors"
 	"github.com/Chronicle20/atlas-tenant"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel"
+	"sync/atomic"
 	"time"
 )
 
 const TimeoutTask = "timeout"
 
 type Timeout struct {
-	l        logrus.FieldLogger
-	interval time.Duration
-	timeout  time.Duration
+	l         logrus.FieldLogger
+	r         Registry
+	interval  time.Duration
+	retention time.Duration
+	b         *Broker
+	lastRun   atomic.Int64
 }
 
-func NewInviteTimeout(l logrus.FieldLogger, interval time.Duration) *Timeout {
-	var to int64 = 180000
-	timeout := time.Duration(to) * time.Millisecond
-	l.Infof("Initializing invite timeout task to run every %dms, timeout invite older than %dms", interval.Milliseconds(), timeout.Milliseconds())
-	return &Timeout{l, interval, timeout}
+// NewInviteTimeout creates a task which expires pending invites past their deadline, forgets invites which were resolved
+// more than retention ago, and prunes the record of processed commands.
+func NewInviteTimeout(l logrus.FieldLogger, interval time.Duration, retention time.Duration) *Timeout {
+	l.Infof("Initializing invite timeout task to run every %dms.", interval.Milliseconds())
+	return &Timeout{l: l, r: GetRegistry(), interval: interval, retention: retention, b: GetBroker()}
 }
 
 func (t *Timeout) Run() {
-	_, span := otel.GetTracerProvider().Tracer("atlas-invites").Start(context.Background(), TimeoutTask)
+	ctx, span := otel.GetTracerProvider().Tracer("atlas-invites").Start(context.Background(), TimeoutTask)
 	defer span.End()
+	defer metrics.TimeoutTask(time.Now())
 
-	is, err := GetRegistry().GetExpired(t.timeout)
+	is, err := t.r.GetExpired()
 	if err != nil {
 		return
 	}
+	defer t.lastRun.Store(time.Now().UnixNano())
 
 	t.l.Debugf("Executing timeout task.")
 	for _, i := range is {
 		t.l.Infof("Invite [%d] has expired. Character [%d] will no longer be able to act upon it.", i.Id(), i.TargetId())
-		err = GetRegistry().Delete(i.Tenant(), i.TargetId(), i.Type(), i.OriginatorId())
+		transactionId := uuid.New()
+		var ei Model
+		err = t.r.Transaction(func(r Registry, s outbox.Store, _ message.Recorder) error {
+			var err error
+			ei, err = r.ResolveById(i.Tenant(), i.Id(), StatusExpired, time.Now())
+			if err != nil {
+				return err
+			}
+			return outbox.ProviderImpl(s)(tenant.WithContext(ctx, i.Tenant()))(invite2.EnvEventStatusTopic)(expiredStatusEventProvider(i.Id(), i.ReferenceId(), i.WorldId(), i.Type(), i.OriginatorId(), i.TargetId(), i.Age(), i.Ttl(), transactionId))
+		})
+		if errors.Is(err, ErrNotFound) {
+			t.l.Debugf("Invite [%d] was resolved before it could be expired.", i.Id())
+			continue
+		}
 		if err != nil {
 			t.l.WithError(err).Errorf("Unable to expire invite [%d].", i.Id())
-			return
+			continue
 		}
+		metrics.StatusEvent(i.Tenant(), i.Type(), invite2.EventInviteStatusTypeExpired)
+		ap := audit.NewProcessor(t.l, tenant.WithContext(ctx, i.Tenant()))
+		_ = ap.Record(ap.Transition(string(StatusExpired), i.WorldId(), i.Type(), i.ReferenceId(), i.OriginatorId(), i.TargetId(), 0, "", transactionId))
+		t.b.Publish(Update{eventType: invite2.EventInviteStatusTypeExpired, invite: ei})
+	}
+	outbox.Notify()
 
-		ctx := tenant.WithContext(context.Background(), i.Tenant())
-		transactionId := uuid.New()
-		err = producer.ProviderImpl(t.l)(ctx)(invite2.EnvEventStatusTopic)(rejectedStatusEventProvider(i.ReferenceId(), i.WorldId(), i.Type(), i.OriginatorId(), i.TargetId(), transactionId))
-		if err != nil {
-			t.l.WithError(err).Errorf("Unable to produce rejection event for [%d] denying [%d] [%s] due to timeout.", i.TargetId(), i.OriginatorId(), i.Type())
-		}
+	err = t.r.DeleteExpiredCooldowns()
+	if err != nil {
+		t.l.WithError(err).Errorf("Unable to delete expired re-invite cooldowns.")
+	}
+
+	err = t.r.PruneResolved(time.Now().Add(-t.retention))
+	if err != nil {
+		t.l.WithError(err).Errorf("Unable to prune resolved invites.")
+	}
+
+	err = transaction.GetStore().Prune()
+	if err != nil {
+		t.l.WithError(err).Errorf("Unable to prune processed transactions.")
+	}
+}
+
+// LastRun returns when the task last completed a sweep of expired invites, or the zero time if it has not yet done so.
+func (t *Timeout) LastRun() time.Time {
+	n := t.lastRun.Load()
+	if n == 0 {
+		return time.Time{}
 	}
+	return time.Unix(0, n)
 }
 
 func (t *Timeout) SleepTime() time.Duration {
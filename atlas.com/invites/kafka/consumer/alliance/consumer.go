@@ -0,0 +1,46 @@
+package alliance
+
+import (
+	invite3 "atlas-invites/invite"
+	consumer2 "atlas-invites/kafka/consumer"
+	alliance2 "atlas-invites/kafka/message/alliance"
+	invite2 "atlas-invites/kafka/message/invite"
+	"atlas-invites/metrics"
+	"context"
+	"github.com/Chronicle20/atlas-kafka/consumer"
+	"github.com/Chronicle20/atlas-kafka/handler"
+	"github.com/Chronicle20/atlas-kafka/message"
+	"github.com/Chronicle20/atlas-kafka/topic"
+	"github.com/Chronicle20/atlas-model/model"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const consumerName = "alliance_status_event"
+
+func InitConsumers(l logrus.FieldLogger) func(func(config consumer.Config, decorators ...model.Decorator[consumer.Config])) func(consumerGroupId string) {
+	return func(rf func(config consumer.Config, decorators ...model.Decorator[consumer.Config])) func(consumerGroupId string) {
+		return func(consumerGroupId string) {
+			rf(consumer2.NewConfig(l)(consumerName)(alliance2.EnvEventStatusTopic)(consumerGroupId), consumer.SetHeaderParsers(consumer.SpanHeaderParser, consumer.TenantHeaderParser))
+		}
+	}
+}
+
+func InitHandlers(l logrus.FieldLogger) func(rf func(topic string, handler handler.Handler) (string, error)) {
+	return func(rf func(topic string, handler handler.Handler) (string, error)) {
+		var t string
+		t, _ = topic.EnvProvider(l)(alliance2.EnvEventStatusTopic)()
+		_, _ = rf(t, message.AdaptHandler(message.PersistentConfig(handleDisbandedEvent)))
+	}
+}
+
+func handleDisbandedEvent(l logrus.FieldLogger, ctx context.Context, e alliance2.StatusEvent[alliance2.DisbandedEventBody]) {
+	if e.Type != alliance2.EventAllianceStatusTypeDisbanded {
+		return
+	}
+	_, err := invite3.NewProcessor(l, ctx).CancelByReferenceAndEmit(invite2.InviteTypeAlliance, e.AllianceId, uuid.New())
+	if err != nil {
+		metrics.HandlerError(consumerName, e.Type)
+		l.WithError(err).Errorf("Unable to cancel invites for alliance [%d].", e.AllianceId)
+	}
+}
@@ -0,0 +1,65 @@
+package character
+
+import (
+	invite3 "atlas-invites/invite"
+	consumer2 "atlas-invites/kafka/consumer"
+	character2 "atlas-invites/kafka/message/character"
+	"atlas-invites/metrics"
+	"context"
+	"github.com/Chronicle20/atlas-kafka/consumer"
+	"github.com/Chronicle20/atlas-kafka/handler"
+	"github.com/Chronicle20/atlas-kafka/message"
+	"github.com/Chronicle20/atlas-kafka/topic"
+	"github.com/Chronicle20/atlas-model/model"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const consumerName = "character_status_event"
+
+func InitConsumers(l logrus.FieldLogger) func(func(config consumer.Config, decorators ...model.Decorator[consumer.Config])) func(consumerGroupId string) {
+	return func(rf func(config consumer.Config, decorators ...model.Decorator[consumer.Config])) func(consumerGroupId string) {
+		return func(consumerGroupId string) {
+			rf(consumer2.NewConfig(l)(consumerName)(character2.EnvEventTopicCharacterStatus)(consumerGroupId), consumer.SetHeaderParsers(consumer.SpanHeaderParser, consumer.TenantHeaderParser))
+		}
+	}
+}
+
+func InitHandlers(l logrus.FieldLogger) func(rf func(topic string, handler handler.Handler) (string, error)) {
+	return func(rf func(topic string, handler handler.Handler) (string, error)) {
+		var t string
+		t, _ = topic.EnvProvider(l)(character2.EnvEventTopicCharacterStatus)()
+		_, _ = rf(t, message.AdaptHandler(message.PersistentConfig(handleLogoutEvent)))
+		_, _ = rf(t, message.AdaptHandler(message.PersistentConfig(handleChannelChangedEvent)))
+		_, _ = rf(t, message.AdaptHandler(message.PersistentConfig(handleDeletedEvent)))
+	}
+}
+
+func handleLogoutEvent(l logrus.FieldLogger, ctx context.Context, e character2.StatusEvent[character2.StatusEventLogoutBody]) {
+	if e.Type != character2.EventCharacterStatusTypeLogout {
+		return
+	}
+	purge(l, ctx, e.Type, e.CharacterId)
+}
+
+func handleChannelChangedEvent(l logrus.FieldLogger, ctx context.Context, e character2.StatusEvent[character2.StatusEventChannelChangedBody]) {
+	if e.Type != character2.EventCharacterStatusTypeChannelChanged {
+		return
+	}
+	purge(l, ctx, e.Type, e.CharacterId)
+}
+
+func handleDeletedEvent(l logrus.FieldLogger, ctx context.Context, e character2.StatusEvent[character2.StatusEventDeletedBody]) {
+	if e.Type != character2.EventCharacterStatusTypeDeleted {
+		return
+	}
+	purge(l, ctx, e.Type, e.CharacterId)
+}
+
+func purge(l logrus.FieldLogger, ctx context.Context, eventType string, characterId uint32) {
+	_, err := invite3.NewProcessor(l, ctx).PurgeAndEmit(eventType, characterId, uuid.New())
+	if err != nil {
+		metrics.HandlerError(consumerName, eventType)
+		l.WithError(err).Errorf("Unable to purge invites for character [%d] after [%s].", characterId, eventType)
+	}
+}
@@ -0,0 +1,46 @@
+package guild
+
+import (
+	invite3 "atlas-invites/invite"
+	consumer2 "atlas-invites/kafka/consumer"
+	guild2 "atlas-invites/kafka/message/guild"
+	invite2 "atlas-invites/kafka/message/invite"
+	"atlas-invites/metrics"
+	"context"
+	"github.com/Chronicle20/atlas-kafka/consumer"
+	"github.com/Chronicle20/atlas-kafka/handler"
+	"github.com/Chronicle20/atlas-kafka/message"
+	"github.com/Chronicle20/atlas-kafka/topic"
+	"github.com/Chronicle20/atlas-model/model"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const consumerName = "guild_status_event"
+
+func InitConsumers(l logrus.FieldLogger) func(func(config consumer.Config, decorators ...model.Decorator[consumer.Config])) func(consumerGroupId string) {
+	return func(rf func(config consumer.Config, decorators ...model.Decorator[consumer.Config])) func(consumerGroupId string) {
+		return func(consumerGroupId string) {
+			rf(consumer2.NewConfig(l)(consumerName)(guild2.EnvEventStatusTopic)(consumerGroupId), consumer.SetHeaderParsers(consumer.SpanHeaderParser, consumer.TenantHeaderParser))
+		}
+	}
+}
+
+func InitHandlers(l logrus.FieldLogger) func(rf func(topic string, handler handler.Handler) (string, error)) {
+	return func(rf func(topic string, handler handler.Handler) (string, error)) {
+		var t string
+		t, _ = topic.EnvProvider(l)(guild2.EnvEventStatusTopic)()
+		_, _ = rf(t, message.AdaptHandler(message.PersistentConfig(handleDisbandedEvent)))
+	}
+}
+
+func handleDisbandedEvent(l logrus.FieldLogger, ctx context.Context, e guild2.StatusEvent[guild2.DisbandedEventBody]) {
+	if e.Type != guild2.EventGuildStatusTypeDisbanded {
+		return
+	}
+	_, err := invite3.NewProcessor(l, ctx).CancelByReferenceAndEmit(invite2.InviteTypeGuild, e.GuildId, uuid.New())
+	if err != nil {
+		metrics.HandlerError(consumerName, e.Type)
+		l.WithError(err).Errorf("Unable to cancel invites for guild [%d].", e.GuildId)
+	}
+}
@@ -0,0 +1,41 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"strings"
+)
+
+// InitClientId gives the Kafka connections of this process a client id no other process shares, so its consumer group
+// membership can be told apart from that of other instances.
+func InitClientId(name string) {
+	kafka.DefaultClientID = fmt.Sprintf("%s-%s", name, uuid.New().String())
+}
+
+// GroupJoinedCheck reports whether this instance is a member of the consumer group. Members are matched to this instance
+// by the client id set with InitClientId, so a rebalance caused by another instance does not mark this one unready.
+func GroupJoinedCheck(groupId string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		c := &kafka.Client{Addr: kafka.TCP(LookupBrokers()...)}
+		resp, err := c.DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: []string{groupId}})
+		if err != nil {
+			return err
+		}
+		if len(resp.Groups) == 0 {
+			return errors.New("consumer group not found")
+		}
+		g := resp.Groups[0]
+		if g.Error != nil {
+			return g.Error
+		}
+		for _, m := range g.Members {
+			if m.ClientID == kafka.DefaultClientID || strings.HasPrefix(m.MemberID, kafka.DefaultClientID+"-") {
+				return nil
+			}
+		}
+		return fmt.Errorf("not a member of consumer group, which is %s with %d members", g.GroupState, len(g.Members))
+	}
+}
@@ -1,22 +1,31 @@
 package invite
 
 import (
+	"atlas-invites/audit"
 	invite3 "atlas-invites/invite"
 	consumer2 "atlas-invites/kafka/consumer"
+	message2 "atlas-invites/kafka/message"
 	invite2 "atlas-invites/kafka/message/invite"
+	"atlas-invites/metrics"
 	"context"
+	"errors"
+	"fmt"
 	"github.com/Chronicle20/atlas-kafka/consumer"
 	"github.com/Chronicle20/atlas-kafka/handler"
 	"github.com/Chronicle20/atlas-kafka/message"
 	"github.com/Chronicle20/atlas-kafka/topic"
 	"github.com/Chronicle20/atlas-model/model"
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
+const consumerName = "invite_command"
+
 func InitConsumers(l logrus.FieldLogger) func(func(config consumer.Config, decorators ...model.Decorator[consumer.Config])) func(consumerGroupId string) {
 	return func(rf func(config consumer.Config, decorators ...model.Decorator[consumer.Config])) func(consumerGroupId string) {
 		return func(consumerGroupId string) {
-			rf(consumer2.NewConfig(l)("invite_command")(invite2.EnvCommandTopic)(consumerGroupId), consumer.SetHeaderParsers(consumer.SpanHeaderParser, consumer.TenantHeaderParser))
+			rf(consumer2.NewConfig(l)(consumerName)(invite2.EnvCommandTopic)(consumerGroupId), consumer.SetHeaderParsers(consumer.SpanHeaderParser, consumer.TenantHeaderParser))
 		}
 	}
 }
@@ -28,6 +37,7 @@ func InitHandlers(l logrus.FieldLogger) func(rf func(topic string, handler handl
 		_, _ = rf(t, message.AdaptHandler(message.PersistentConfig(handleCreateCommand)))
 		_, _ = rf(t, message.AdaptHandler(message.PersistentConfig(handleAcceptCommand)))
 		_, _ = rf(t, message.AdaptHandler(message.PersistentConfig(handleRejectCommand)))
+		_, _ = rf(t, message.AdaptHandler(message.PersistentConfig(handleCancelCommand)))
 	}
 }
 
@@ -35,19 +45,80 @@ func handleCreateCommand(l logrus.FieldLogger, ctx context.Context, c invite2.Co
 	if c.Type != invite2.CommandInviteTypeCreate {
 		return
 	}
-	_, _ = invite3.NewProcessor(l, ctx).CreateAndEmit(c.Body.ReferenceId, c.WorldId, c.InviteType, c.Body.OriginatorId, c.Body.TargetId, c.TransactionId)
+	received(l, ctx, c.Type, c.WorldId, c.InviteType, c.Body.ReferenceId, c.Body.OriginatorId, c.Body.TargetId, c.TransactionId)
+	p := invite3.NewProcessor(l, ctx)
+	err := emitOnce(ctx, p, c.TransactionId, c.Type)(failed(c.Body.ReferenceId, c.WorldId, c.InviteType, c.Type, c.Body.OriginatorId, c.Body.TargetId, c.TransactionId))(func(p invite3.Processor, buf *message2.Buffer) error {
+		_, err := p.Create(buf)(c.Body.ReferenceId)(c.WorldId)(c.InviteType)(c.Body.OriginatorId)(c.Body.TargetId)(c.TransactionId)
+		return err
+	})
+	if err != nil && !errors.Is(err, invite3.ErrPreferenceDeclined) {
+		metrics.HandlerError(consumerName, c.Type)
+	}
 }
 
 func handleAcceptCommand(l logrus.FieldLogger, ctx context.Context, c invite2.CommandEvent[invite2.AcceptCommandBody]) {
 	if c.Type != invite2.CommandInviteTypeAccept {
 		return
 	}
-	_, _ = invite3.NewProcessor(l, ctx).AcceptAndEmit(c.Body.ReferenceId, c.WorldId, c.InviteType, c.Body.TargetId, c.TransactionId)
+	received(l, ctx, c.Type, c.WorldId, c.InviteType, c.Body.ReferenceId, 0, c.Body.TargetId, c.TransactionId)
+	p := invite3.NewProcessor(l, ctx)
+	err := emitOnce(ctx, p, c.TransactionId, c.Type)(failed(c.Body.ReferenceId, c.WorldId, c.InviteType, c.Type, 0, c.Body.TargetId, c.TransactionId))(func(p invite3.Processor, buf *message2.Buffer) error {
+		_, err := p.Accept(buf)(c.Body.ReferenceId)(c.WorldId)(c.InviteType)(c.Body.TargetId)(c.TransactionId)
+		return err
+	})
+	if err != nil {
+		metrics.HandlerError(consumerName, c.Type)
+	}
 }
 
 func handleRejectCommand(l logrus.FieldLogger, ctx context.Context, c invite2.CommandEvent[invite2.RejectCommandBody]) {
 	if c.Type != invite2.CommandInviteTypeReject {
 		return
 	}
-	_, _ = invite3.NewProcessor(l, ctx).RejectAndEmit(c.Body.OriginatorId, c.WorldId, c.InviteType, c.Body.TargetId, c.TransactionId)
+	received(l, ctx, c.Type, c.WorldId, c.InviteType, 0, c.Body.OriginatorId, c.Body.TargetId, c.TransactionId)
+	p := invite3.NewProcessor(l, ctx)
+	err := emitOnce(ctx, p, c.TransactionId, c.Type)(failed(0, c.WorldId, c.InviteType, c.Type, c.Body.OriginatorId, c.Body.TargetId, c.TransactionId))(func(p invite3.Processor, buf *message2.Buffer) error {
+		_, err := p.Reject(buf)(c.Body.OriginatorId)(c.WorldId)(c.InviteType)(c.Body.TargetId)(c.TransactionId)
+		return err
+	})
+	if err != nil {
+		metrics.HandlerError(consumerName, c.Type)
+	}
+}
+
+func handleCancelCommand(l logrus.FieldLogger, ctx context.Context, c invite2.CommandEvent[invite2.CancelCommandBody]) {
+	if c.Type != invite2.CommandInviteTypeCancel {
+		return
+	}
+	received(l, ctx, c.Type, c.WorldId, c.InviteType, c.Body.ReferenceId, c.Body.OriginatorId, c.Body.TargetId, c.TransactionId)
+	p := invite3.NewProcessor(l, ctx)
+	err := emitOnce(ctx, p, c.TransactionId, c.Type)(failed(c.Body.ReferenceId, c.WorldId, c.InviteType, c.Type, c.Body.OriginatorId, c.Body.TargetId, c.TransactionId))(func(p invite3.Processor, buf *message2.Buffer) error {
+		_, err := p.Cancel(buf)(c.Body.ReferenceId)(c.WorldId)(c.InviteType)(c.Body.OriginatorId)(c.Body.TargetId)(c.TransactionId)
+		return err
+	})
+	if err != nil {
+		metrics.HandlerError(consumerName, c.Type)
+	}
+}
+
+// emitOnce processes a command at most once per transaction. A redelivered command re-emits the events produced when
+// it was first processed, including the ERROR event reporting its failure, instead of acting on the registry again.
+func emitOnce(ctx context.Context, p invite3.Processor, transactionId uuid.UUID, commandType string) func(fail func(p invite3.Processor, buf *message2.Buffer, cause error) error) func(f func(p invite3.Processor, buf *message2.Buffer) error) error {
+	t := tenant.MustFromContext(ctx)
+	key := fmt.Sprintf("%s:%s:%s", t.Id().String(), transactionId.String(), commandType)
+	return p.EmitOnce(key)
+}
+
+// failed reports the failure of a command with an ERROR event.
+func failed(referenceId uint32, worldId byte, inviteType string, commandType string, originatorId uint32, targetId uint32, transactionId uuid.UUID) func(p invite3.Processor, buf *message2.Buffer, cause error) error {
+	return func(p invite3.Processor, buf *message2.Buffer, cause error) error {
+		return p.Error(buf)(referenceId)(worldId)(inviteType)(commandType)(originatorId)(targetId)(transactionId)(cause)
+	}
+}
+
+// received records a command in the audit log as it arrives, before it is acted upon. Redelivered commands are recorded
+// each time they are received.
+func received(l logrus.FieldLogger, ctx context.Context, commandType string, worldId byte, inviteType string, referenceId uint32, originatorId uint32, targetId uint32, transactionId uuid.UUID) {
+	ap := audit.NewProcessor(l, ctx)
+	_ = ap.Record(ap.Command(commandType, worldId, inviteType, referenceId, originatorId, targetId, invite3.CommandActor(commandType, originatorId, targetId), transactionId))
 }
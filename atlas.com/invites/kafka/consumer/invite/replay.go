@@ -0,0 +1,99 @@
+package invite
+
+import (
+	invite3 "atlas-invites/invite"
+	consumer2 "atlas-invites/kafka/consumer"
+	invite2 "atlas-invites/kafka/message/invite"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/Chronicle20/atlas-kafka/consumer"
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+	"io"
+	"time"
+)
+
+type statusEventBody struct {
+	OriginatorId uint32 `json:"originatorId"`
+	TargetId     uint32 `json:"targetId"`
+	Reason       string `json:"reason"`
+}
+
+// ReplayStatusEvents rebuilds the invite registry from the status events produced within the lookback window. It
+// must complete before the command consumer is started.
+func ReplayStatusEvents(l logrus.FieldLogger) func(ctx context.Context) func(lookback time.Duration) error {
+	return func(ctx context.Context) func(lookback time.Duration) error {
+		return func(lookback time.Duration) error {
+			rs, err := consumer2.NewReplayReaders(l)(ctx)(invite2.EnvEventStatusTopic)(time.Now().Add(-lookback))
+			if err != nil {
+				return err
+			}
+			return Replay(l)(ctx)(rs...)
+		}
+	}
+}
+
+// Replay folds every status event yielded by the readers into the registry, closing each reader once exhausted.
+func Replay(l logrus.FieldLogger) func(ctx context.Context) func(rs ...consumer2.Reader) error {
+	return func(ctx context.Context) func(rs ...consumer2.Reader) error {
+		return func(rs ...consumer2.Reader) error {
+			return replay(l, ctx, headerTenant, rs...)
+		}
+	}
+}
+
+// tenantResolver identifies the tenant a status event was produced for.
+type tenantResolver func(ctx context.Context, m kafka.Message) (tenant.Model, error)
+
+func headerTenant(ctx context.Context, m kafka.Message) (tenant.Model, error) {
+	return tenant.FromContext(consumer.TenantHeaderParser(ctx, m.Headers))()
+}
+
+func replay(l logrus.FieldLogger, ctx context.Context, tenantOf tenantResolver, rs ...consumer2.Reader) error {
+	var count int
+	for _, r := range rs {
+		n, err := replayReader(l, ctx, tenantOf, r)
+		_ = r.Close()
+		if err != nil {
+			return err
+		}
+		count += n
+	}
+	l.Infof("Replayed [%d] invite status events.", count)
+	return nil
+}
+
+func replayReader(l logrus.FieldLogger, ctx context.Context, tenantOf tenantResolver, r consumer2.Reader) (int, error) {
+	var count int
+	for {
+		m, err := r.ReadMessage(ctx)
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+		err = replayMessage(ctx, tenantOf, m)
+		if err != nil {
+			l.WithError(err).Warnf("Unable to replay invite status event at offset [%d] of partition [%d].", m.Offset, m.Partition)
+			continue
+		}
+		count++
+	}
+}
+
+func replayMessage(ctx context.Context, tenantOf tenantResolver, m kafka.Message) error {
+	t, err := tenantOf(ctx, m)
+	if err != nil {
+		return err
+	}
+
+	var e invite2.StatusEvent[statusEventBody]
+	err = json.Unmarshal(m.Value, &e)
+	if err != nil {
+		return err
+	}
+	return invite3.Fold(invite3.GetRegistry(), invite3.GetExpirationPolicy(), invite3.GetReinvitePolicy())(t)(e.Type, e.InviteId, e.WorldId, e.InviteType, e.ReferenceId, e.Body.OriginatorId, e.Body.TargetId, e.Body.Reason, m.Time)
+}
@@ -0,0 +1,150 @@
+package invite
+
+import (
+	invite3 "atlas-invites/invite"
+	invite2 "atlas-invites/kafka/message/invite"
+	"context"
+	"encoding/json"
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus/hooks/test"
+	"io"
+	"testing"
+	"time"
+)
+
+// sliceReader yields its messages in order and then io.EOF, as a replay reader does at the end of its partition.
+type sliceReader struct {
+	ms     []kafka.Message
+	closed bool
+}
+
+func (r *sliceReader) ReadMessage(_ context.Context) (kafka.Message, error) {
+	if len(r.ms) == 0 {
+		return kafka.Message{}, io.EOF
+	}
+	m := r.ms[0]
+	r.ms = r.ms[1:]
+	return m, nil
+}
+
+func (r *sliceReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+func statusMessage[E any](t *testing.T, at time.Time, eventType string, inviteId uint32, inviteType string, referenceId uint32, body E) kafka.Message {
+	v, err := json.Marshal(invite2.StatusEvent[E]{
+		TransactionId: uuid.New(),
+		InviteType:    inviteType,
+		InviteId:      inviteId,
+		ReferenceId:   referenceId,
+		Type:          eventType,
+		Body:          body,
+	})
+	if err != nil {
+		t.Fatalf("Unable to encode status event: %v", err)
+	}
+	return kafka.Message{Value: v, Time: at}
+}
+
+func created(t *testing.T, at time.Time, inviteId uint32, inviteType string, referenceId uint32, originatorId uint32, targetId uint32) kafka.Message {
+	return statusMessage(t, at, invite2.EventInviteStatusTypeCreated, inviteId, inviteType, referenceId, invite2.CreatedEventBody{OriginatorId: originatorId, TargetId: targetId})
+}
+
+func rejected(t *testing.T, at time.Time, inviteId uint32, inviteType string, referenceId uint32, originatorId uint32, targetId uint32, reason string) kafka.Message {
+	return statusMessage(t, at, invite2.EventInviteStatusTypeRejected, inviteId, inviteType, referenceId, invite2.RejectedEventBody{OriginatorId: originatorId, TargetId: targetId, Reason: reason})
+}
+
+func TestReplay(t *testing.T) {
+	l, _ := test.NewNullLogger()
+	tm, err := tenant.Create(uuid.New(), "GMS", 83, 1)
+	if err != nil {
+		t.Fatalf("Unable to create tenant: %v", err)
+	}
+	tenantOf := func(_ context.Context, _ kafka.Message) (tenant.Model, error) {
+		return tm, nil
+	}
+
+	now := time.Now()
+	a := &sliceReader{ms: []kafka.Message{
+		created(t, now.Add(-time.Minute), 7, invite2.InviteTypeBuddy, 1, 1, 2),
+		created(t, now.Add(-time.Minute), 9, invite2.InviteTypeBuddy, 4, 4, 2),
+		rejected(t, now.Add(-time.Minute), 9, invite2.InviteTypeBuddy, 4, 4, 2, invite2.RejectReasonRequested),
+		created(t, now.Add(-2*time.Hour), 11, invite2.InviteTypeBuddy, 8, 8, 2),
+		rejected(t, now.Add(-time.Hour), 11, invite2.InviteTypeBuddy, 8, 8, 2, invite2.RejectReasonRequested),
+		rejected(t, now, 0, invite2.InviteTypeBuddy, 5, 5, 6, invite2.RejectReasonPreferenceDeclined),
+		{Value: []byte("{")},
+	}}
+	b := &sliceReader{ms: []kafka.Message{
+		created(t, now.Add(-time.Minute), 8, invite2.InviteTypeParty, 100, 3, 2),
+		created(t, now.Add(-time.Minute), 10, invite2.InviteTypeParty, 200, 5, 6),
+		statusMessage(t, now, invite2.EventInviteStatusTypeAccepted, 10, invite2.InviteTypeParty, 200, invite2.AcceptedEventBody{OriginatorId: 5, TargetId: 6}),
+		// a duplicate of an event already folded changes nothing.
+		created(t, now.Add(-time.Minute), 7, invite2.InviteTypeBuddy, 1, 1, 2),
+	}}
+
+	err = replay(l, context.Background(), tenantOf, a, b)
+	if err != nil {
+		t.Fatalf("Unable to replay status events: %v", err)
+	}
+	if !a.closed || !b.closed {
+		t.Errorf("Expected every reader to be closed.")
+	}
+
+	r := invite3.GetRegistry()
+	pending, err := r.GetForCharacter(tm, 2)
+	if err != nil {
+		t.Fatalf("Unable to get invites: %v", err)
+	}
+	if len(pending) != 2 {
+		t.Fatalf("Expected 2 pending invites, got %d.", len(pending))
+	}
+	for _, id := range []uint32{7, 8} {
+		i, err := r.GetById(tm, id)
+		if err != nil {
+			t.Fatalf("Expected invite [%d] to be restored under its id: %v", id, err)
+		}
+		if i.Status() != invite3.StatusPending {
+			t.Errorf("Expected invite [%d] to be pending, got [%s].", id, i.Status())
+		}
+	}
+	if i, _ := r.GetById(tm, 7); i.OriginatorId() != 1 || i.TargetId() != 2 || i.ReferenceId() != 1 {
+		t.Errorf("Expected invite [7] to be restored as created.")
+	}
+	for id, status := range map[uint32]invite3.Status{9: invite3.StatusRejected, 10: invite3.StatusAccepted} {
+		i, err := r.GetById(tm, id)
+		if err != nil {
+			t.Fatalf("Unable to get invite [%d]: %v", id, err)
+		}
+		if i.Status() != status {
+			t.Errorf("Expected invite [%d] to be [%s], got [%s].", id, status, i.Status())
+		}
+	}
+
+	cs, err := r.GetCooldowns(tm, 4, 2)
+	if err != nil {
+		t.Fatalf("Unable to get cooldowns: %v", err)
+	}
+	if len(cs) != 1 || cs[0].Remaining() > 4*time.Minute {
+		t.Errorf("Expected the rejection to restore the remainder of its cooldown.")
+	}
+	cs, _ = r.GetCooldowns(tm, 8, 2)
+	if len(cs) != 0 {
+		t.Errorf("Expected a cooldown which ran out before the replay not to be restored.")
+	}
+	cs, _ = r.GetCooldowns(tm, 5, 6)
+	if len(cs) != 0 {
+		t.Errorf("Expected a rejection declined by preference not to be folded.")
+	}
+
+	// ids allocated after the replay follow those restored.
+	m, _, err := r.Create(tm, 12, 0, 13, invite2.InviteTypeBuddy, 12, now, time.Minute, invite3.ExclusivityPolicy{}, invite3.CapacityPolicy{})
+	if err != nil {
+		t.Fatalf("Unable to create invite: %v", err)
+	}
+	if m.Id() <= 11 {
+		t.Errorf("Expected a new invite id beyond those restored, got [%d].", m.Id())
+	}
+}
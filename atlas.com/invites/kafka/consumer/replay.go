@@ -0,0 +1,106 @@
+package consumer
+
+import (
+	"context"
+	"github.com/Chronicle20/atlas-kafka/topic"
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+	"io"
+	"time"
+)
+
+// Reader yields messages from a topic until it is exhausted, at which point ReadMessage returns io.EOF.
+type Reader interface {
+	ReadMessage(ctx context.Context) (kafka.Message, error)
+	Close() error
+}
+
+// NewReplayReaders opens one Reader per partition of the topic identified by token. Each Reader starts at the first
+// message produced after since and stops at the end of the partition as observed when it was opened.
+func NewReplayReaders(l logrus.FieldLogger) func(ctx context.Context) func(token string) func(since time.Time) ([]Reader, error) {
+	return func(ctx context.Context) func(token string) func(since time.Time) ([]Reader, error) {
+		return func(token string) func(since time.Time) ([]Reader, error) {
+			return func(since time.Time) ([]Reader, error) {
+				t, err := topic.EnvProvider(l)(token)()
+				if err != nil {
+					return nil, err
+				}
+				brokers := LookupBrokers()
+
+				conn, err := kafka.DialContext(ctx, "tcp", brokers[0])
+				if err != nil {
+					return nil, err
+				}
+				defer conn.Close()
+
+				ps, err := conn.ReadPartitions(t)
+				if err != nil {
+					return nil, err
+				}
+
+				rs := make([]Reader, 0)
+				for _, p := range ps {
+					var r Reader
+					r, err = newBoundedReader(ctx, brokers, t, p.ID, since)
+					if err != nil {
+						for _, r := range rs {
+							_ = r.Close()
+						}
+						return nil, err
+					}
+					rs = append(rs, r)
+				}
+				return rs, nil
+			}
+		}
+	}
+}
+
+type boundedReader struct {
+	r    *kafka.Reader
+	done bool
+	end  int64
+}
+
+func newBoundedReader(ctx context.Context, brokers []string, topic string, partition int, since time.Time) (Reader, error) {
+	lc, err := kafka.DialLeader(ctx, "tcp", brokers[0], topic, partition)
+	if err != nil {
+		return nil, err
+	}
+	defer lc.Close()
+
+	start, err := lc.ReadOffset(since)
+	if err != nil {
+		return nil, err
+	}
+	end, err := lc.ReadLastOffset()
+	if err != nil {
+		return nil, err
+	}
+
+	r := kafka.NewReader(kafka.ReaderConfig{Brokers: brokers, Topic: topic, Partition: partition})
+	err = r.SetOffset(start)
+	if err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	return &boundedReader{r: r, done: start >= end, end: end}, nil
+}
+
+func (b *boundedReader) ReadMessage(ctx context.Context) (kafka.Message, error) {
+	if b.done {
+		return kafka.Message{}, io.EOF
+	}
+	m, err := b.r.ReadMessage(ctx)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	if m.Offset+1 >= b.end {
+		b.done = true
+	}
+	return m, nil
+}
+
+func (b *boundedReader) Close() error {
+	return b.r.Close()
+}
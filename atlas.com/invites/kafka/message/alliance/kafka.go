@@ -0,0 +1,17 @@
+package alliance
+
+const (
+	EnvEventStatusTopic              = "EVENT_TOPIC_ALLIANCE_STATUS"
+	EventAllianceStatusTypeDisbanded = "DISBANDED"
+)
+
+type StatusEvent[E any] struct {
+	WorldId    byte   `json:"worldId"`
+	AllianceId uint32 `json:"allianceId"`
+	Type       string `json:"type"`
+	Body       E      `json:"body"`
+}
+
+type DisbandedEventBody struct {
+	Guilds []uint32 `json:"guilds"`
+}
@@ -0,0 +1,29 @@
+package character
+
+const (
+	EnvEventTopicCharacterStatus           = "EVENT_TOPIC_CHARACTER_STATUS"
+	EventCharacterStatusTypeLogout         = "LOGOUT"
+	EventCharacterStatusTypeChannelChanged = "CHANNEL_CHANGED"
+	EventCharacterStatusTypeDeleted        = "DELETED"
+)
+
+type StatusEvent[E any] struct {
+	CharacterId uint32 `json:"characterId"`
+	Type        string `json:"type"`
+	WorldId     byte   `json:"worldId"`
+	Body        E      `json:"body"`
+}
+
+type StatusEventLogoutBody struct {
+	ChannelId byte   `json:"channelId"`
+	MapId     uint32 `json:"mapId"`
+}
+
+type StatusEventChannelChangedBody struct {
+	ChannelId    byte   `json:"channelId"`
+	OldChannelId byte   `json:"oldChannelId"`
+	MapId        uint32 `json:"mapId"`
+}
+
+type StatusEventDeletedBody struct {
+}
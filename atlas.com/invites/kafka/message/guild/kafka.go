@@ -0,0 +1,17 @@
+package guild
+
+const (
+	EnvEventStatusTopic           = "EVENT_TOPIC_GUILD_STATUS"
+	EventGuildStatusTypeDisbanded = "DISBANDED"
+)
+
+type StatusEvent[E any] struct {
+	WorldId byte   `json:"worldId"`
+	GuildId uint32 `json:"guildId"`
+	Type    string `json:"type"`
+	Body    E      `json:"body"`
+}
+
+type DisbandedEventBody struct {
+	Members []uint32 `json:"members"`
+}
@@ -2,6 +2,7 @@ package invite
 
 import (
 	"github.com/google/uuid"
+	"time"
 )
 
 const (
@@ -9,11 +10,35 @@ const (
 	CommandInviteTypeCreate = "CREATE"
 	CommandInviteTypeAccept = "ACCEPT"
 	CommandInviteTypeReject = "REJECT"
+	CommandInviteTypeCancel = "CANCEL"
 
-	EnvEventStatusTopic           = "EVENT_TOPIC_INVITE_STATUS"
-	EventInviteStatusTypeCreated  = "CREATED"
-	EventInviteStatusTypeAccepted = "ACCEPTED"
-	EventInviteStatusTypeRejected = "REJECTED"
+	EnvEventStatusTopic            = "EVENT_TOPIC_INVITE_STATUS"
+	EventInviteStatusTypeCreated   = "CREATED"
+	EventInviteStatusTypeAccepted  = "ACCEPTED"
+	EventInviteStatusTypeRejected  = "REJECTED"
+	EventInviteStatusTypeCancelled = "CANCELLED"
+	EventInviteStatusTypeExpired   = "EXPIRED"
+	EventInviteStatusTypeError     = "ERROR"
+
+	ErrorReasonNotFound            = "NOT_FOUND"
+	ErrorReasonAlreadyPending      = "ALREADY_PENDING"
+	ErrorReasonSelfInvite          = "SELF_INVITE"
+	ErrorReasonInvalidType         = "INVALID_TYPE"
+	ErrorReasonRateLimited         = "RATE_LIMITED"
+	ErrorReasonBlocked             = "BLOCKED"
+	ErrorReasonCooldown            = "COOLDOWN"
+	ErrorReasonCapacityExceeded    = "CAPACITY_EXCEEDED"
+	ErrorReasonExclusivityConflict = "EXCLUSIVITY_CONFLICT"
+	ErrorReasonUnknown             = "UNKNOWN"
+
+	RejectReasonRequested          = "REQUESTED"
+	RejectReasonPreferenceDeclined = "PREFERENCE_DECLINED"
+
+	CancelReasonRequested            = "REQUESTED"
+	CancelReasonCharacterUnavailable = "CHARACTER_UNAVAILABLE"
+	CancelReasonReferenceDisbanded   = "REFERENCE_DISBANDED"
+	CancelReasonReplaced             = "REPLACED"
+	CancelReasonSuperseded           = "SUPERSEDED"
 
 	InviteTypeBuddy        = "BUDDY"
 	InviteTypeFamily       = "FAMILY"
@@ -25,6 +50,8 @@ const (
 	InviteTypeAlliance     = "ALLIANCE"
 )
 
+var InviteTypes = []string{InviteTypeBuddy, InviteTypeFamily, InviteTypeFamilySummon, InviteTypeMessenger, InviteTypeTrade, InviteTypeParty, InviteTypeGuild, InviteTypeAlliance}
+
 type CommandEvent[E any] struct {
 	TransactionId uuid.UUID `json:"transactionId"`
 	WorldId       byte      `json:"worldId"`
@@ -49,10 +76,17 @@ type RejectCommandBody struct {
 	OriginatorId uint32 `json:"originatorId"`
 }
 
+type CancelCommandBody struct {
+	OriginatorId uint32 `json:"originatorId"`
+	TargetId     uint32 `json:"targetId"`
+	ReferenceId  uint32 `json:"referenceId"`
+}
+
 type StatusEvent[E any] struct {
 	TransactionId uuid.UUID `json:"transactionId"`
 	WorldId       byte      `json:"worldId"`
 	InviteType    string    `json:"inviteType"`
+	InviteId      uint32    `json:"inviteId,omitempty"`
 	ReferenceId   uint32    `json:"referenceId"`
 	Type          string    `json:"type"`
 	Body          E         `json:"body"`
@@ -71,4 +105,25 @@ type AcceptedEventBody struct {
 type RejectedEventBody struct {
 	OriginatorId uint32 `json:"originatorId"`
 	TargetId     uint32 `json:"targetId"`
+	Reason       string `json:"reason"`
+}
+
+type CancelledEventBody struct {
+	OriginatorId uint32 `json:"originatorId"`
+	TargetId     uint32 `json:"targetId"`
+	Reason       string `json:"reason"`
+}
+
+type ExpiredEventBody struct {
+	OriginatorId uint32    `json:"originatorId"`
+	TargetId     uint32    `json:"targetId"`
+	CreatedAt    time.Time `json:"createdAt"`
+	Ttl          int64     `json:"ttl"`
+}
+
+type ErrorEventBody struct {
+	CommandType  string `json:"commandType"`
+	Reason       string `json:"reason"`
+	OriginatorId uint32 `json:"originatorId"`
+	TargetId     uint32 `json:"targetId"`
 }
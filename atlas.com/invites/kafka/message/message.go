@@ -58,6 +58,12 @@ func Emit(p producer.Provider) func(f func(buf *Buffer) error) error {
 	}
 }
 
+// Recorder retains the messages emitted for a key so they can be emitted again when the same work is redelivered.
+type Recorder interface {
+	Get(key string) (map[string][]kafka.Message, bool, error)
+	Put(key string, messages map[string][]kafka.Message) error
+}
+
 func EmitWithResult[M any, B any](p producer.Provider) func(func(*Buffer) func(B) (M, error)) func(B) (M, error) {
 	return func(f func(*Buffer) func(B) (M, error)) func(B) (M, error) {
 		return func(input B) (M, error) {
@@ -0,0 +1,17 @@
+package messenger
+
+const (
+	EnvEventStatusTopic               = "EVENT_TOPIC_MESSENGER_STATUS"
+	EventMessengerStatusTypeDisbanded = "DISBANDED"
+)
+
+type StatusEvent[E any] struct {
+	ActorId     uint32 `json:"actorId"`
+	WorldId     byte   `json:"worldId"`
+	MessengerId uint32 `json:"messengerId"`
+	Type        string `json:"type"`
+	Body        E      `json:"body"`
+}
+
+type DisbandedEventBody struct {
+}
@@ -0,0 +1,18 @@
+package party
+
+const (
+	EnvEventStatusTopic         = "EVENT_TOPIC_PARTY_STATUS"
+	EventPartyStatusTypeDisband = "DISBAND"
+)
+
+type StatusEvent[E any] struct {
+	ActorId uint32 `json:"actorId"`
+	WorldId byte   `json:"worldId"`
+	PartyId uint32 `json:"partyId"`
+	Type    string `json:"type"`
+	Body    E      `json:"body"`
+}
+
+type DisbandEventBody struct {
+	Members []uint32 `json:"members"`
+}
@@ -0,0 +1,31 @@
+package producer
+
+import (
+	"context"
+	"github.com/Chronicle20/atlas-kafka/topic"
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+	"os"
+)
+
+// BrokerCheck reports whether a broker can be reached and serves metadata for the topic identified by token.
+func BrokerCheck(l logrus.FieldLogger) func(token string) func(ctx context.Context) error {
+	return func(token string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			t, err := topic.EnvProvider(l)(token)()
+			if err != nil {
+				return err
+			}
+			conn, err := kafka.DialContext(ctx, "tcp", os.Getenv("BOOTSTRAP_SERVERS"))
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			if dl, ok := ctx.Deadline(); ok {
+				_ = conn.SetDeadline(dl)
+			}
+			_, err = conn.ReadPartitions(t)
+			return err
+		}
+	}
+}
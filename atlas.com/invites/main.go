@@ -1,21 +1,40 @@
 package main
 
 import (
+	"atlas-invites/audit"
+	"atlas-invites/block"
 	"atlas-invites/character"
+	"atlas-invites/database"
+	"atlas-invites/health"
 	"atlas-invites/invite"
+	consumer2 "atlas-invites/kafka/consumer"
+	"atlas-invites/kafka/consumer/alliance"
+	character2 "atlas-invites/kafka/consumer/character"
+	"atlas-invites/kafka/consumer/guild"
 	invite2 "atlas-invites/kafka/consumer/invite"
+	"atlas-invites/kafka/consumer/messenger"
+	"atlas-invites/kafka/consumer/party"
+	invite3 "atlas-invites/kafka/message/invite"
+	"atlas-invites/kafka/producer"
 	"atlas-invites/logger"
+	"atlas-invites/metrics"
+	"atlas-invites/outbox"
+	"atlas-invites/preference"
 	"atlas-invites/service"
 	"atlas-invites/tasks"
 	"atlas-invites/tracing"
+	"atlas-invites/transaction"
 	"github.com/Chronicle20/atlas-kafka/consumer"
 	"github.com/Chronicle20/atlas-rest/server"
+	"gorm.io/gorm"
 	"os"
 	"time"
 )
 
 const serviceName = "atlas-invites"
 const consumerGroupId = "Invitation Service"
+const storageTypePostgres = "POSTGRES"
+const timeoutInterval = 5 * time.Second
 
 type Server struct {
 	baseUrl string
@@ -42,15 +61,128 @@ func main() {
 	l.Infoln("Starting main service.")
 
 	tdm := service.GetTeardownManager()
+	consumer2.InitClientId(serviceName)
 
 	tc, err := tracing.InitTracer(l)(serviceName)
 	if err != nil {
 		l.WithError(err).Fatal("Unable to initialize tracer.")
 	}
 
+	var db *gorm.DB
+	if os.Getenv("STORAGE_TYPE") == storageTypePostgres {
+		db = database.Connect(l, database.SetMigrations(invite.Migration, outbox.Migration, block.Migration, preference.Migration, audit.Migration, transaction.Migration))
+		invite.InitRegistry(invite.NewDatabaseRegistry(db))
+		block.InitRegistry(block.NewDatabaseRegistry(db))
+		preference.InitRegistry(preference.NewDatabaseRegistry(db))
+		outbox.InitStore(outbox.NewDatabaseStore(db))
+	}
+
+	as, err := audit.SinkFromEnv(db)
+	if err != nil {
+		l.WithError(err).Fatal("Unable to configure invite audit log.")
+	}
+	audit.InitSink(as)
+
+	ep, err := invite.ExpirationPolicyFromEnv()
+	if err != nil {
+		l.WithError(err).Fatal("Unable to load invite expiration policy.")
+	}
+	invite.InitExpirationPolicy(ep)
+
+	pp, err := invite.PurgePolicyFromEnv()
+	if err != nil {
+		l.WithError(err).Fatal("Unable to load invite purge policy.")
+	}
+	invite.InitPurgePolicy(pp)
+
+	rip, err := invite.ReinvitePolicyFromEnv()
+	if err != nil {
+		l.WithError(err).Fatal("Unable to load invite re-invite cooldown policy.")
+	}
+	invite.InitReinvitePolicy(rip)
+
+	sp, err := invite.SymmetryPolicyFromEnv()
+	if err != nil {
+		l.WithError(err).Fatal("Unable to load invite symmetry policy.")
+	}
+	invite.InitSymmetryPolicy(sp)
+
+	xp, err := invite.ExclusivityPolicyFromEnv()
+	if err != nil {
+		l.WithError(err).Fatal("Unable to load invite exclusivity policy.")
+	}
+	invite.InitExclusivityPolicy(xp)
+
+	cp, err := invite.CapacityPolicyFromEnv()
+	if err != nil {
+		l.WithError(err).Fatal("Unable to load invite capacity policy.")
+	}
+	invite.InitCapacityPolicy(cp)
+
+	rp, err := invite.RateLimitPolicyFromEnv()
+	if err != nil {
+		l.WithError(err).Fatal("Unable to load invite rate limit policy.")
+	}
+	invite.InitRateLimiter(invite.NewRateLimiter(rp))
+
+	ts, err := transaction.StoreFromEnv(db)
+	if err != nil {
+		l.WithError(err).Fatal("Unable to configure transaction cache.")
+	}
+	transaction.InitStore(ts)
+
+	if val, ok := os.LookupEnv("BOOTSTRAP_REPLAY_LOOKBACK"); ok {
+		if db != nil {
+			l.Fatalf("BOOTSTRAP_REPLAY_LOOKBACK is not supported with STORAGE_TYPE [%s], which already keeps invites across restarts.", storageTypePostgres)
+		}
+		lookback, err := time.ParseDuration(val)
+		if err != nil {
+			l.WithError(err).Fatalf("Unable to parse replay lookback [%s].", val)
+		}
+		err = invite2.ReplayStatusEvents(l)(tdm.Context())(lookback)
+		if err != nil {
+			l.WithError(err).Fatal("Unable to rebuild invite registry from status events.")
+		}
+	}
+
+	ma, err := outbox.MaxAttemptsFromEnv()
+	if err != nil {
+		l.WithError(err).Fatal("Unable to load outbox max attempts.")
+	}
+	outbox.NewRelay(l, outbox.GetStore(), time.Second, ma).Start(tdm.Context(), tdm.WaitGroup())
+
 	cmf := consumer.GetManager().AddConsumer(l, tdm.Context(), tdm.WaitGroup())
 	invite2.InitConsumers(l)(cmf)(consumerGroupId)
+	character2.InitConsumers(l)(cmf)(consumerGroupId)
+	party.InitConsumers(l)(cmf)(consumerGroupId)
+	guild.InitConsumers(l)(cmf)(consumerGroupId)
+	alliance.InitConsumers(l)(cmf)(consumerGroupId)
+	messenger.InitConsumers(l)(cmf)(consumerGroupId)
 	invite2.InitHandlers(l)(consumer.GetManager().RegisterHandler)
+	character2.InitHandlers(l)(consumer.GetManager().RegisterHandler)
+	party.InitHandlers(l)(consumer.GetManager().RegisterHandler)
+	guild.InitHandlers(l)(consumer.GetManager().RegisterHandler)
+	alliance.InitHandlers(l)(consumer.GetManager().RegisterHandler)
+	messenger.InitHandlers(l)(consumer.GetManager().RegisterHandler)
+
+	err = metrics.RegisterPending(invite.GetRegistry().CountPending)
+	if err != nil {
+		l.WithError(err).Fatal("Unable to register pending invite metrics.")
+	}
+
+	retention, err := invite.RetentionFromEnv()
+	if err != nil {
+		l.WithError(err).Fatal("Unable to load invite retention period.")
+	}
+	timeout := invite.NewInviteTimeout(l, timeoutInterval, retention)
+
+	hc := health.NewChecker(tdm.Context(), 2*time.Second).
+		AddCheck("producer", producer.BrokerCheck(l)(invite3.EnvEventStatusTopic)).
+		AddCheck("consumers", consumer2.GroupJoinedCheck(consumerGroupId)).
+		AddCheck("timeout", health.Ticked(timeout.LastRun, 3*timeoutInterval))
+	if db != nil {
+		hc.AddCheck("storage", database.PingCheck(db))
+	}
 
 	// Create the service with the router
 	server.New(l).
@@ -59,9 +191,15 @@ func main() {
 		SetBasePath(GetServer().GetPrefix()).
 		SetPort(os.Getenv("REST_PORT")).
 		AddRouteInitializer(character.InitResource(GetServer())).
+		AddRouteInitializer(invite.InitResource(GetServer())).
+		AddRouteInitializer(block.InitResource(GetServer())).
+		AddRouteInitializer(preference.InitResource(GetServer())).
+		AddRouteInitializer(health.InitResource(hc)).
 		Run()
 
-	go tasks.Register(l, tdm.Context())(invite.NewInviteTimeout(l, time.Second*time.Duration(5)))
+	metrics.Serve(l, tdm.Context(), tdm.WaitGroup(), os.Getenv("METRICS_PORT"))
+
+	go tasks.Register(l, tdm.Context())(timeout)
 
 	tdm.TeardownFunc(tracing.Teardown(l)(tc))
 
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+const namespace = "atlas_invites"
+
+var (
+	statusEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "status_events_total",
+		Help:      "Invite status events emitted, by tenant, invite type and event type.",
+	}, []string{"tenant", "invite_type", "event"})
+
+	decisionSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "decision_seconds",
+		Help:      "Time between an invite being created and its target accepting or rejecting it.",
+		Buckets:   []float64{1, 2.5, 5, 10, 20, 30, 60, 120, 180, 300, 600},
+	}, []string{"tenant", "invite_type", "event"})
+
+	rateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Invite creations refused by the rate limiter, by tenant, invite type and limiting scope.",
+	}, []string{"tenant", "invite_type", "scope"})
+
+	handlerErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_handler_errors_total",
+		Help:      "Kafka messages whose handling failed, by consumer and message type.",
+	}, []string{"consumer", "type"})
+
+	timeoutTaskSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "timeout_task_duration_seconds",
+		Help:      "Duration of each invite timeout task run.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+	})
+)
+
+func init() {
+	prometheus.MustRegister(statusEvents, decisionSeconds, rateLimited, handlerErrors, timeoutTaskSeconds)
+}
+
+// StatusEvent records an invite status event of eventType being emitted.
+func StatusEvent(t tenant.Model, inviteType string, eventType string) {
+	statusEvents.WithLabelValues(t.Id().String(), inviteType, eventType).Inc()
+}
+
+// Decision records the target of an invite created at age accepting or rejecting it, as described by eventType.
+func Decision(t tenant.Model, inviteType string, eventType string, age time.Time) {
+	decisionSeconds.WithLabelValues(t.Id().String(), inviteType, eventType).Observe(time.Since(age).Seconds())
+}
+
+// RateLimited records the creation of an invite of inviteType being refused by the rate limiter's scope.
+func RateLimited(t tenant.Model, inviteType string, scope string) {
+	rateLimited.WithLabelValues(t.Id().String(), inviteType, scope).Inc()
+}
+
+// HandlerError records a Kafka message of messageType which the named consumer failed to handle.
+func HandlerError(consumer string, messageType string) {
+	handlerErrors.WithLabelValues(consumer, messageType).Inc()
+}
+
+// TimeoutTask records the duration of a timeout task run which started at start.
+func TimeoutTask(start time.Time) {
+	timeoutTaskSeconds.Observe(time.Since(start).Seconds())
+}
+
+// PendingCounter reports the number of pending invites by tenant id and invite type.
+type PendingCounter func() (map[uuid.UUID]map[string]int, error)
+
+type pendingCollector struct {
+	c    PendingCounter
+	desc *prometheus.Desc
+}
+
+// RegisterPending exposes a gauge of pending invites which is computed by c each time metrics are collected, so it
+// remains accurate regardless of how invites entered or left the registry.
+func RegisterPending(c PendingCounter) error {
+	return prometheus.Register(&pendingCollector{
+		c:    c,
+		desc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "pending"), "Pending invites, by tenant and invite type.", []string{"tenant", "invite_type"}, nil),
+	})
+}
+
+func (p *pendingCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.desc
+}
+
+func (p *pendingCollector) Collect(ch chan<- prometheus.Metric) {
+	cs, err := p.c()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(p.desc, err)
+		return
+	}
+	for tenantId, types := range cs {
+		for inviteType, count := range types {
+			ch <- prometheus.MustNewConstMetric(p.desc, prometheus.GaugeValue, float64(count), tenantId.String(), inviteType)
+		}
+	}
+}
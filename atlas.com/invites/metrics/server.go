@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const DefaultPort = "9100"
+
+// Serve exposes collected metrics at /metrics on port until ctx is cancelled.
+func Serve(l logrus.FieldLogger, ctx context.Context, wg *sync.WaitGroup, port string) {
+	if port == "" {
+		port = DefaultPort
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	srv := &http.Server{
+		Addr:              ":" + port,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		l.Infof("Serving metrics on port [%s].", port)
+		err := srv.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			l.WithError(err).Errorf("Metrics server stopped unexpectedly.")
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(sctx)
+	}()
+}
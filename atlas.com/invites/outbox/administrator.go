@@ -0,0 +1,50 @@
+package outbox
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/segmentio/kafka-go"
+	"gorm.io/gorm"
+	"time"
+)
+
+func create(db *gorm.DB, t tenant.Model, token string, headers map[string]string, ms []kafka.Message, nextAttemptAt time.Time) error {
+	if len(ms) == 0 {
+		return nil
+	}
+	es := make([]Entity, 0, len(ms))
+	for _, m := range ms {
+		es = append(es, Entity{
+			TenantId:      t.Id(),
+			Region:        t.Region(),
+			MajorVersion:  t.MajorVersion(),
+			MinorVersion:  t.MinorVersion(),
+			Token:         token,
+			Headers:       headers,
+			Key:           m.Key,
+			Value:         m.Value,
+			NextAttemptAt: nextAttemptAt,
+		})
+	}
+	return db.Create(&es).Error
+}
+
+func deleteById(db *gorm.DB, id uint64) error {
+	return db.Where("id = ?", id).Delete(&Entity{}).Error
+}
+
+func deferById(db *gorm.DB, id uint64, nextAttemptAt time.Time) error {
+	return db.Model(&Entity{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"next_attempt_at": nextAttemptAt,
+	}).Error
+}
+
+// failById moves the entry to the dead letter table, counting the attempt which failed.
+func failById(db *gorm.DB, id uint64, failedAt time.Time) error {
+	err := db.Exec(`INSERT INTO outbox_dead_letters (id, tenant_id, region, major_version, minor_version, token, headers, key, value, attempts, failed_at)
+		SELECT id, tenant_id, region, major_version, minor_version, token, headers, key, value, attempts + 1, ? FROM outbox_entries WHERE id = ?`, failedAt, id).Error
+	if err != nil {
+		return err
+	}
+	return deleteById(db, id)
+}
@@ -0,0 +1,66 @@
+package outbox
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+func Migration(db *gorm.DB) error {
+	return db.AutoMigrate(&Entity{}, &DeadLetterEntity{})
+}
+
+type Entity struct {
+	Id            uint64            `gorm:"primaryKey;autoIncrement;not null"`
+	TenantId      uuid.UUID         `gorm:"type:uuid;not null"`
+	Region        string            `gorm:"not null"`
+	MajorVersion  uint16            `gorm:"not null"`
+	MinorVersion  uint16            `gorm:"not null"`
+	Token         string            `gorm:"not null"`
+	Headers       map[string]string `gorm:"serializer:json"`
+	Key           []byte
+	Value         []byte
+	Attempts      uint32    `gorm:"not null;default:0"`
+	NextAttemptAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+func (e Entity) TableName() string {
+	return "outbox_entries"
+}
+
+func Make(e Entity) (Entry, error) {
+	t, err := tenant.Create(e.TenantId, e.Region, e.MajorVersion, e.MinorVersion)
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{
+		id:            e.Id,
+		tenant:        t,
+		token:         e.Token,
+		headers:       e.Headers,
+		key:           e.Key,
+		value:         e.Value,
+		attempts:      e.Attempts,
+		nextAttemptAt: e.NextAttemptAt,
+	}, nil
+}
+
+// DeadLetterEntity is an entry the relay gave up publishing, kept for inspection.
+type DeadLetterEntity struct {
+	Id           uint64            `gorm:"primaryKey;autoIncrement:false;not null"`
+	TenantId     uuid.UUID         `gorm:"type:uuid;not null"`
+	Region       string            `gorm:"not null"`
+	MajorVersion uint16            `gorm:"not null"`
+	MinorVersion uint16            `gorm:"not null"`
+	Token        string            `gorm:"not null"`
+	Headers      map[string]string `gorm:"serializer:json"`
+	Key          []byte
+	Value        []byte
+	Attempts     uint32    `gorm:"not null"`
+	FailedAt     time.Time `gorm:"not null"`
+}
+
+func (e DeadLetterEntity) TableName() string {
+	return "outbox_dead_letters"
+}
@@ -0,0 +1,7 @@
+package outbox
+
+import "errors"
+
+var (
+	ErrInvalidMaxAttempts = errors.New("outbox max attempts must be positive")
+)
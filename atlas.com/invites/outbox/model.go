@@ -0,0 +1,53 @@
+package outbox
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/segmentio/kafka-go"
+	"time"
+)
+
+// Entry is a message awaiting publication. token identifies the topic through its environment variable, as accepted
+// by producer.Provider. headers carry the span the message was produced in, so the trace continues once it is published.
+type Entry struct {
+	id            uint64
+	tenant        tenant.Model
+	token         string
+	headers       map[string]string
+	key           []byte
+	value         []byte
+	attempts      uint32
+	nextAttemptAt time.Time
+}
+
+func (e Entry) Id() uint64 {
+	return e.id
+}
+
+func (e Entry) Tenant() tenant.Model {
+	return e.tenant
+}
+
+func (e Entry) Token() string {
+	return e.token
+}
+
+func (e Entry) Headers() map[string]string {
+	return e.headers
+}
+
+func (e Entry) Message() kafka.Message {
+	return kafka.Message{Key: e.key, Value: e.value}
+}
+
+func (e Entry) Attempts() uint32 {
+	return e.attempts
+}
+
+func (e Entry) NextAttemptAt() time.Time {
+	return e.nextAttemptAt
+}
+
+// Due reports whether the relay may attempt to publish the entry at now.
+func (e Entry) Due(now time.Time) bool {
+	return !now.Before(e.nextAttemptAt)
+}
@@ -0,0 +1,58 @@
+package outbox
+
+import (
+	"atlas-invites/kafka/producer"
+	"context"
+	producer2 "github.com/Chronicle20/atlas-kafka/producer"
+	"github.com/Chronicle20/atlas-model/model"
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/opentracing/opentracing-go"
+	"github.com/segmentio/kafka-go"
+)
+
+// ProviderImpl returns a producer.Provider which adds messages to s rather than publishing them, on behalf of the
+// tenant in ctx. The relay publishes them once the work that produced them has been committed, continuing the span
+// held by ctx.
+func ProviderImpl(s Store) func(ctx context.Context) producer.Provider {
+	return func(ctx context.Context) producer.Provider {
+		t := tenant.MustFromContext(ctx)
+		headers := spanHeaders(ctx)
+		return func(token string) producer2.MessageProducer {
+			return func(provider model.Provider[[]kafka.Message]) error {
+				ms, err := provider()
+				if err != nil {
+					return err
+				}
+				return s.Add(t, token, headers, ms)
+			}
+		}
+	}
+}
+
+// spanHeaders returns the headers identifying the span held by ctx, or nil when there is none.
+func spanHeaders(ctx context.Context) map[string]string {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return nil
+	}
+	headers := make(map[string]string)
+	err := opentracing.GlobalTracer().Inject(span.Context(), opentracing.TextMap, opentracing.TextMapCarrier(headers))
+	if err != nil || len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
+
+// spanContext returns a context holding a span which follows from the one identified by headers, along with a function
+// finishing it. ctx is returned as is when headers do not identify a span.
+func spanContext(ctx context.Context, name string, headers map[string]string) (context.Context, func()) {
+	if len(headers) == 0 {
+		return ctx, func() {}
+	}
+	sc, err := opentracing.GlobalTracer().Extract(opentracing.TextMap, opentracing.TextMapCarrier(headers))
+	if err != nil {
+		return ctx, func() {}
+	}
+	span := opentracing.StartSpan(name, opentracing.FollowsFrom(sc))
+	return opentracing.ContextWithSpan(ctx, span), span.Finish
+}
@@ -0,0 +1,39 @@
+package outbox
+
+import (
+	"atlas-invites/database"
+	"github.com/Chronicle20/atlas-model/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func getPending(limit int) database.EntityProvider[[]Entity] {
+	return func(db *gorm.DB) model.Provider[[]Entity] {
+		var results []Entity
+		err := db.Order("id").Limit(limit).Find(&results).Error
+		if err != nil {
+			return model.ErrorProvider[[]Entity](err)
+		}
+		return model.FixedProvider(results)
+	}
+}
+
+// getClaimable locks up to limit of the oldest entries until the surrounding transaction ends, skipping those already
+// locked.
+func getClaimable(limit int) database.EntityProvider[[]Entity] {
+	return func(db *gorm.DB) model.Provider[[]Entity] {
+		var results []Entity
+		err := db.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).Order("id").Limit(limit).Find(&results).Error
+		if err != nil {
+			return model.ErrorProvider[[]Entity](err)
+		}
+		return model.FixedProvider(results)
+	}
+}
+
+// getOldestId returns the id of the oldest entry, whether or not it is locked, or 0 when there are none.
+func getOldestId(db *gorm.DB) (uint64, error) {
+	var id uint64
+	err := db.Model(&Entity{}).Select("COALESCE(MIN(id), 0)").Scan(&id).Error
+	return id, err
+}
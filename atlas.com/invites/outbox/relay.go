@@ -0,0 +1,178 @@
+package outbox
+
+import (
+	"atlas-invites/kafka/producer"
+	"context"
+	"github.com/Chronicle20/atlas-model/model"
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+	"maps"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultMaxAttempts = 20
+
+	batchSize  = 100
+	minBackoff = 100 * time.Millisecond
+	maxBackoff = 30 * time.Second
+	relaySpan  = "outbox_relay"
+)
+
+var wake = make(chan struct{}, 1)
+
+// Notify wakes the relay so newly committed entries are published without waiting for the next poll.
+func Notify() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// Relay publishes outbox entries to Kafka. Entries are published oldest first and removed once Kafka has accepted
+// them, so a message may be published more than once but is never published ahead of an earlier one. An entry which
+// cannot be published within maxAttempts is failed, so it no longer holds back the entries behind it.
+type Relay struct {
+	l           logrus.FieldLogger
+	s           Store
+	interval    time.Duration
+	maxAttempts uint32
+}
+
+func NewRelay(l logrus.FieldLogger, s Store, interval time.Duration, maxAttempts uint32) *Relay {
+	l.Infof("Initializing outbox relay to poll every %dms.", interval.Milliseconds())
+	return &Relay{l: l, s: s, interval: interval, maxAttempts: maxAttempts}
+}
+
+// MaxAttemptsFromEnv reads OUTBOX_MAX_ATTEMPTS, how many times the relay attempts to publish an entry before failing
+// it. When it is unset DefaultMaxAttempts applies.
+func MaxAttemptsFromEnv() (uint32, error) {
+	val, ok := os.LookupEnv("OUTBOX_MAX_ATTEMPTS")
+	if !ok || val == "" {
+		return DefaultMaxAttempts, nil
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, ErrInvalidMaxAttempts
+	}
+	return uint32(n), nil
+}
+
+// Start runs the relay until ctx is cancelled, making a final attempt to publish pending entries before it stops.
+func (r *Relay) Start(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				r.Flush()
+				r.l.Infof("Stopping outbox relay.")
+				return
+			case <-wake:
+			case <-time.After(r.interval):
+			}
+			r.Flush()
+		}
+	}()
+}
+
+// Flush publishes every due entry the relay can claim. It stops at the first entry which is not yet due or cannot be
+// published, deferring the latter with an exponential backoff, or failing it once it has used up its attempts.
+func (r *Relay) Flush() {
+	for {
+		var fetched int
+		var stopped bool
+		err := r.s.Claim(batchSize, func(s Store, es []Entry) error {
+			fetched = len(es)
+			stopped = r.drain(s, es)
+			return nil
+		})
+		if err != nil {
+			r.l.WithError(err).Errorf("Unable to claim pending outbox entries.")
+			return
+		}
+		if stopped || fetched < batchSize {
+			return
+		}
+	}
+}
+
+// drain publishes the due entries of es in order, removing each once published. It reports whether it stopped ahead of
+// the end of es.
+func (r *Relay) drain(s Store, es []Entry) bool {
+	now := time.Now()
+	for len(es) > 0 {
+		if !es[0].Due(now) {
+			return true
+		}
+		run := nextRun(es, now)
+		err := r.publish(run)
+		if err == nil {
+			for _, e := range run {
+				err = s.Remove(e.Id())
+				if err != nil {
+					r.l.WithError(err).Errorf("Unable to remove published outbox entry [%d].", e.Id())
+					return true
+				}
+			}
+			es = es[len(run):]
+			continue
+		}
+
+		head := run[0]
+		if head.Attempts()+1 < r.maxAttempts {
+			r.l.WithError(err).Warnf("Unable to publish outbox entry [%d] to [%s] after [%d] attempts.", head.Id(), head.Token(), head.Attempts()+1)
+			err = s.Defer(head.Id(), now.Add(backoff(head.Attempts())))
+			if err != nil {
+				r.l.WithError(err).Errorf("Unable to defer outbox entry [%d].", head.Id())
+			}
+			return true
+		}
+		r.l.WithError(err).Errorf("Giving up on outbox entry [%d] to [%s] after [%d] attempts.", head.Id(), head.Token(), head.Attempts()+1)
+		err = s.Fail(head.Id())
+		if err != nil {
+			r.l.WithError(err).Errorf("Unable to fail outbox entry [%d].", head.Id())
+			return true
+		}
+		es = es[1:]
+	}
+	return false
+}
+
+// nextRun returns the leading due entries which share a tenant, topic and span, so they can be published in one call.
+func nextRun(es []Entry, now time.Time) []Entry {
+	n := 1
+	for n < len(es) && es[n].Due(now) && es[n].Tenant().Id() == es[0].Tenant().Id() && es[n].Token() == es[0].Token() && maps.Equal(es[n].Headers(), es[0].Headers()) {
+		n++
+	}
+	return es[:n]
+}
+
+func (r *Relay) publish(es []Entry) error {
+	ms := make([]kafka.Message, 0, len(es))
+	for _, e := range es {
+		ms = append(ms, e.Message())
+	}
+	ctx, finish := spanContext(tenant.WithContext(context.Background(), es[0].Tenant()), relaySpan, es[0].Headers())
+	defer finish()
+	return producer.ProviderImpl(r.l)(ctx)(es[0].Token())(model.FixedProvider(ms))
+}
+
+func backoff(attempts uint32) time.Duration {
+	d := minBackoff
+	for i := uint32(0); i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		return maxBackoff
+	}
+	return d
+}
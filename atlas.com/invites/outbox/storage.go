@@ -0,0 +1,65 @@
+package outbox
+
+import (
+	"atlas-invites/database"
+	"github.com/Chronicle20/atlas-model/model"
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/segmentio/kafka-go"
+	"gorm.io/gorm"
+	"time"
+)
+
+// DatabaseStore is a Store backed by the outbox_entries table. When constructed with an open transaction, entries are
+// committed or rolled back together with the other work performed in that transaction.
+type DatabaseStore struct {
+	db *gorm.DB
+}
+
+func NewDatabaseStore(db *gorm.DB) *DatabaseStore {
+	return &DatabaseStore{db: db}
+}
+
+func (s *DatabaseStore) Add(t tenant.Model, token string, headers map[string]string, ms []kafka.Message) error {
+	return create(s.db, t, token, headers, ms, time.Now())
+}
+
+func (s *DatabaseStore) Pending(limit int) ([]Entry, error) {
+	return model.SliceMap(Make)(getPending(limit)(s.db))()()
+}
+
+// Claim locks up to limit of the oldest entries for the duration of a transaction, skipping entries locked by another
+// relay. When an older entry is locked, nothing is claimed, so the relay holding it publishes it ahead of those behind
+// it.
+func (s *DatabaseStore) Claim(limit int, f func(s Store, es []Entry) error) error {
+	return database.ExecuteTransaction(s.db, func(tx *gorm.DB) error {
+		es, err := model.SliceMap(Make)(getClaimable(limit)(tx))()()
+		if err != nil {
+			return err
+		}
+		if len(es) > 0 {
+			oldest, err := getOldestId(tx)
+			if err != nil {
+				return err
+			}
+			if oldest < es[0].Id() {
+				return nil
+			}
+		}
+		return f(NewDatabaseStore(tx), es)
+	})
+}
+
+func (s *DatabaseStore) Remove(id uint64) error {
+	return deleteById(s.db, id)
+}
+
+func (s *DatabaseStore) Defer(id uint64, nextAttemptAt time.Time) error {
+	return deferById(s.db, id, nextAttemptAt)
+}
+
+// Fail moves the entry to the outbox_dead_letters table.
+func (s *DatabaseStore) Fail(id uint64) error {
+	return database.ExecuteTransaction(s.db, func(tx *gorm.DB) error {
+		return failById(tx, id, time.Now())
+	})
+}
@@ -0,0 +1,129 @@
+package outbox
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/segmentio/kafka-go"
+	"sync"
+	"time"
+)
+
+// Store holds messages until the relay has published them. Pending returns entries oldest first so messages are
+// published in the order they were added. Claim runs f with up to limit of the oldest entries, which no other relay
+// may claim until f returns, and a Store through which f removes, defers or fails them. Fail gives up on an entry,
+// setting it aside so the entries behind it can be published. Implementations must be safe for concurrent use.
+type Store interface {
+	Add(t tenant.Model, token string, headers map[string]string, ms []kafka.Message) error
+	Pending(limit int) ([]Entry, error)
+	Claim(limit int, f func(s Store, es []Entry) error) error
+	Remove(id uint64) error
+	Defer(id uint64, nextAttemptAt time.Time) error
+	Fail(id uint64) error
+}
+
+var store Store
+var once sync.Once
+
+// InitStore sets the Store used by the service, and panics if one is already set.
+func InitStore(s Store) {
+	set := false
+	once.Do(func() {
+		store = s
+		set = true
+	})
+	if !set {
+		panic("outbox store already set")
+	}
+}
+
+// GetStore returns the configured Store, defaulting to an in-memory implementation.
+func GetStore() Store {
+	once.Do(func() {
+		store = NewInMemoryStore()
+	})
+	return store
+}
+
+type InMemoryStore struct {
+	lock    sync.Mutex
+	claim   sync.Mutex
+	nextId  uint64
+	entries []Entry
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{nextId: 1, entries: make([]Entry, 0)}
+}
+
+func (s *InMemoryStore) Add(t tenant.Model, token string, headers map[string]string, ms []kafka.Message) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	for _, m := range ms {
+		s.entries = append(s.entries, Entry{
+			id:            s.nextId,
+			tenant:        t,
+			token:         token,
+			headers:       headers,
+			key:           m.Key,
+			value:         m.Value,
+			nextAttemptAt: now,
+		})
+		s.nextId++
+	}
+	return nil
+}
+
+func (s *InMemoryStore) Pending(limit int) ([]Entry, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	n := len(s.entries)
+	if limit < n {
+		n = limit
+	}
+	return append([]Entry(nil), s.entries[:n]...), nil
+}
+
+func (s *InMemoryStore) Claim(limit int, f func(s Store, es []Entry) error) error {
+	s.claim.Lock()
+	defer s.claim.Unlock()
+
+	es, err := s.Pending(limit)
+	if err != nil {
+		return err
+	}
+	return f(s, es)
+}
+
+func (s *InMemoryStore) Remove(id uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for i, e := range s.entries {
+		if e.id == id {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *InMemoryStore) Defer(id uint64, nextAttemptAt time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for i, e := range s.entries {
+		if e.id == id {
+			s.entries[i].attempts++
+			s.entries[i].nextAttemptAt = nextAttemptAt
+			return nil
+		}
+	}
+	return nil
+}
+
+// Fail discards the entry, as there is nowhere to set it aside in memory.
+func (s *InMemoryStore) Fail(id uint64) error {
+	return s.Remove(id)
+}
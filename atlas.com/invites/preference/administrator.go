@@ -0,0 +1,23 @@
+package preference
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func decline(db *gorm.DB, t tenant.Model, characterId uint32, inviteType string) error {
+	e := Entity{
+		TenantId:     t.Id(),
+		CharacterId:  characterId,
+		InviteType:   inviteType,
+		Region:       t.Region(),
+		MajorVersion: t.MajorVersion(),
+		MinorVersion: t.MinorVersion(),
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&e).Error
+}
+
+func allow(db *gorm.DB, t tenant.Model, characterId uint32, inviteType string) error {
+	return db.Where("tenant_id = ? AND character_id = ? AND invite_type = ?", t.Id(), characterId, inviteType).Delete(&Entity{}).Error
+}
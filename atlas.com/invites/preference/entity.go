@@ -0,0 +1,35 @@
+package preference
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func Migration(db *gorm.DB) error {
+	return db.AutoMigrate(&Entity{})
+}
+
+// Entity records a single invite type a character automatically declines.
+type Entity struct {
+	TenantId     uuid.UUID `gorm:"primaryKey;type:uuid;not null"`
+	CharacterId  uint32    `gorm:"primaryKey;autoIncrement:false;not null"`
+	InviteType   string    `gorm:"primaryKey;not null"`
+	Region       string    `gorm:"not null"`
+	MajorVersion uint16    `gorm:"not null"`
+	MinorVersion uint16    `gorm:"not null"`
+}
+
+func (e Entity) TableName() string {
+	return "invite_preferences"
+}
+
+func Make(t tenant.Model, characterId uint32) func(es []Entity) Model {
+	return func(es []Entity) Model {
+		m := Model{tenant: t, characterId: characterId, declined: make(map[string]bool)}
+		for _, e := range es {
+			m.declined[e.InviteType] = true
+		}
+		return m
+	}
+}
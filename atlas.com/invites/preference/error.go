@@ -0,0 +1,5 @@
+package preference
+
+import "errors"
+
+var ErrInvalidType = errors.New("invalid invite type")
@@ -0,0 +1,38 @@
+package preference
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"sort"
+)
+
+// Model holds a character's invite preferences. Invites of a type the character auto-declines never reach them.
+type Model struct {
+	tenant      tenant.Model
+	characterId uint32
+	declined    map[string]bool
+}
+
+func (m Model) Tenant() tenant.Model {
+	return m.tenant
+}
+
+func (m Model) CharacterId() uint32 {
+	return m.characterId
+}
+
+// Declines reports whether the character automatically declines invites of inviteType.
+func (m Model) Declines(inviteType string) bool {
+	return m.declined[inviteType]
+}
+
+// Declined returns the invite types the character automatically declines, in alphabetical order.
+func (m Model) Declined() []string {
+	results := make([]string, 0, len(m.declined))
+	for t, ok := range m.declined {
+		if ok {
+			results = append(results, t)
+		}
+	}
+	sort.Strings(results)
+	return results
+}
@@ -0,0 +1,71 @@
+package preference
+
+import (
+	invite2 "atlas-invites/kafka/message/invite"
+	"context"
+	"github.com/Chronicle20/atlas-model/model"
+	"github.com/Chronicle20/atlas-tenant"
+	"github.com/sirupsen/logrus"
+	"slices"
+)
+
+type Processor interface {
+	GetByCharacterId(characterId uint32) (Model, error)
+	ByCharacterIdProvider(characterId uint32) model.Provider[Model]
+	Update(characterId uint32, changes map[string]bool) (Model, error)
+	Declines(characterId uint32, inviteType string) (bool, error)
+}
+
+type ProcessorImpl struct {
+	l   logrus.FieldLogger
+	ctx context.Context
+	t   tenant.Model
+	r   Registry
+}
+
+func NewProcessor(l logrus.FieldLogger, ctx context.Context) Processor {
+	return &ProcessorImpl{
+		l:   l,
+		ctx: ctx,
+		t:   tenant.MustFromContext(ctx),
+		r:   GetRegistry(),
+	}
+}
+
+func (p *ProcessorImpl) GetByCharacterId(characterId uint32) (Model, error) {
+	return p.ByCharacterIdProvider(characterId)()
+}
+
+func (p *ProcessorImpl) ByCharacterIdProvider(characterId uint32) model.Provider[Model] {
+	m, err := p.r.Get(p.t, characterId)
+	if err != nil {
+		return model.ErrorProvider[Model](err)
+	}
+	return model.FixedProvider(m)
+}
+
+// Update changes which invite types the character automatically declines. changes is keyed by invite type, where true
+// declines the type and false allows it. Types absent from changes keep their current preference.
+func (p *ProcessorImpl) Update(characterId uint32, changes map[string]bool) (Model, error) {
+	for inviteType := range changes {
+		if !slices.Contains(invite2.InviteTypes, inviteType) {
+			return Model{}, ErrInvalidType
+		}
+	}
+	m, err := p.r.Update(p.t, characterId, changes)
+	if err != nil {
+		p.l.WithError(err).Errorf("Unable to update invite preferences for character [%d].", characterId)
+		return Model{}, err
+	}
+	p.l.Infof("Character [%d] now automatically declines invite types %v.", characterId, m.Declined())
+	return m, nil
+}
+
+// Declines reports whether the character automatically declines invites of inviteType.
+func (p *ProcessorImpl) Declines(characterId uint32, inviteType string) (bool, error) {
+	m, err := p.r.Get(p.t, characterId)
+	if err != nil {
+		return false, err
+	}
+	return m.Declines(inviteType), nil
+}
@@ -0,0 +1,16 @@
+package preference
+
+import (
+	"atlas-invites/database"
+	"github.com/Chronicle20/atlas-model/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func getForCharacter(tenantId uuid.UUID) func(characterId uint32) database.EntityProvider[[]Entity] {
+	return func(characterId uint32) database.EntityProvider[[]Entity] {
+		return func(db *gorm.DB) model.Provider[[]Entity] {
+			return database.SliceQuery[Entity](db, map[string]interface{}{"tenant_id": tenantId, "character_id": characterId})
+		}
+	}
+}
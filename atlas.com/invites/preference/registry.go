@@ -0,0 +1,83 @@
+package preference
+
+import (
+	"github.com/Chronicle20/atlas-tenant"
+	"sync"
+)
+
+// Registry tracks invite preferences. Implementations must be safe for concurrent use. Get returns a Model declining
+// nothing for characters which have never set a preference. Update applies changes keyed by invite type, where true
+// declines the type and false allows it, leaving other types untouched.
+type Registry interface {
+	Get(t tenant.Model, characterId uint32) (Model, error)
+	Update(t tenant.Model, characterId uint32, changes map[string]bool) (Model, error)
+}
+
+var registry Registry
+var once sync.Once
+
+// InitRegistry sets the Registry used by the service, and panics if one is already set.
+func InitRegistry(r Registry) {
+	set := false
+	once.Do(func() {
+		registry = r
+		set = true
+	})
+	if !set {
+		panic("preference registry already set")
+	}
+}
+
+// GetRegistry returns the configured Registry, defaulting to an in-memory implementation.
+func GetRegistry() Registry {
+	once.Do(func() {
+		registry = NewInMemoryRegistry()
+	})
+	return registry
+}
+
+type InMemoryRegistry struct {
+	lock     sync.RWMutex
+	declined map[tenant.Model]map[uint32]map[string]bool
+}
+
+func NewInMemoryRegistry() *InMemoryRegistry {
+	return &InMemoryRegistry{declined: make(map[tenant.Model]map[uint32]map[string]bool)}
+}
+
+func (r *InMemoryRegistry) Get(t tenant.Model, characterId uint32) (Model, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.get(t, characterId), nil
+}
+
+func (r *InMemoryRegistry) Update(t tenant.Model, characterId uint32, changes map[string]bool) (Model, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.declined[t]; !ok {
+		r.declined[t] = make(map[uint32]map[string]bool)
+	}
+	if _, ok := r.declined[t][characterId]; !ok {
+		r.declined[t][characterId] = make(map[string]bool)
+	}
+	for inviteType, declined := range changes {
+		if declined {
+			r.declined[t][characterId][inviteType] = true
+		} else {
+			delete(r.declined[t][characterId], inviteType)
+		}
+	}
+	if len(r.declined[t][characterId]) == 0 {
+		delete(r.declined[t], characterId)
+	}
+	return r.get(t, characterId), nil
+}
+
+func (r *InMemoryRegistry) get(t tenant.Model, characterId uint32) Model {
+	m := Model{tenant: t, characterId: characterId, declined: make(map[string]bool)}
+	for inviteType := range r.declined[t][characterId] {
+		m.declined[inviteType] = true
+	}
+	return m
+}
@@ -0,0 +1,71 @@
+package preference
+
+import (
+	"atlas-invites/rest"
+	"errors"
+	"github.com/Chronicle20/atlas-model/model"
+	"github.com/Chronicle20/atlas-rest/server"
+	"github.com/gorilla/mux"
+	"github.com/jtumidanski/api2go/jsonapi"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+const (
+	GetInvitePreferences    = "get_invite_preferences"
+	UpdateInvitePreferences = "update_invite_preferences"
+)
+
+func InitResource(si jsonapi.ServerInformation) server.RouteInitializer {
+	return func(router *mux.Router, l logrus.FieldLogger) {
+		registerHandler := rest.RegisterHandler(l)(si)
+		registerInputHandler := rest.RegisterInputHandler[RestModel](l)(si)
+		r := router.PathPrefix("/characters/{characterId}/invite-preferences").Subrouter()
+		r.HandleFunc("", registerHandler(GetInvitePreferences, handleGetInvitePreferences)).Methods(http.MethodGet)
+		r.HandleFunc("", registerInputHandler(UpdateInvitePreferences, handleUpdateInvitePreferences)).Methods(http.MethodPatch)
+	}
+}
+
+func handleGetInvitePreferences(d *rest.HandlerDependency, c *rest.HandlerContext) http.HandlerFunc {
+	return rest.ParseCharacterId(d.Logger(), func(characterId uint32) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			res, err := model.Map(Transform)(NewProcessor(d.Logger(), d.Context()).ByCharacterIdProvider(characterId))()
+			if err != nil {
+				d.Logger().WithError(err).Errorf("Creating REST model.")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			query := r.URL.Query()
+			queryParams := jsonapi.ParseQueryFields(&query)
+			server.MarshalResponse[RestModel](d.Logger())(w)(c.ServerInformation())(queryParams)(res)
+		}
+	})
+}
+
+func handleUpdateInvitePreferences(d *rest.HandlerDependency, c *rest.HandlerContext, input RestModel) http.HandlerFunc {
+	return rest.ParseCharacterId(d.Logger(), func(characterId uint32) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			m, err := NewProcessor(d.Logger(), d.Context()).Update(characterId, input.AutoDecline)
+			if errors.Is(err, ErrInvalidType) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			res, err := model.Map(Transform)(model.FixedProvider(m))()
+			if err != nil {
+				d.Logger().WithError(err).Errorf("Creating REST model.")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			query := r.URL.Query()
+			queryParams := jsonapi.ParseQueryFields(&query)
+			server.MarshalResponse[RestModel](d.Logger())(w)(c.ServerInformation())(queryParams)(res)
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package preference
+
+import (
+	invite2 "atlas-invites/kafka/message/invite"
+	"strconv"
+)
+
+type RestModel struct {
+	Id          uint32          `json:"-"`
+	AutoDecline map[string]bool `json:"autoDecline"`
+}
+
+func (r RestModel) GetName() string {
+	return "invite-preferences"
+}
+
+func (r RestModel) GetID() string {
+	return strconv.Itoa(int(r.Id))
+}
+
+func (r *RestModel) SetID(strId string) error {
+	id, err := strconv.Atoi(strId)
+	if err != nil {
+		return err
+	}
+	r.Id = uint32(id)
+	return nil
+}
+
+// Transform reports a preference for every invite type, so clients can render each toggle.
+func Transform(m Model) (RestModel, error) {
+	ad := make(map[string]bool)
+	for _, t := range invite2.InviteTypes {
+		ad[t] = m.Declines(t)
+	}
+	return RestModel{
+		Id:          m.characterId,
+		AutoDecline: ad,
+	}, nil
+}
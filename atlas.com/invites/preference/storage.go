@@ -0,0 +1,45 @@
+package preference
+
+import (
+	"atlas-invites/database"
+	"github.com/Chronicle20/atlas-tenant"
+	"gorm.io/gorm"
+)
+
+// DatabaseRegistry is a Registry backed by a relational database so preferences survive restarts.
+type DatabaseRegistry struct {
+	db *gorm.DB
+}
+
+func NewDatabaseRegistry(db *gorm.DB) *DatabaseRegistry {
+	return &DatabaseRegistry{db: db}
+}
+
+func (r *DatabaseRegistry) Get(t tenant.Model, characterId uint32) (Model, error) {
+	es, err := getForCharacter(t.Id())(characterId)(r.db)()
+	if err != nil {
+		return Model{}, err
+	}
+	return Make(t, characterId)(es), nil
+}
+
+func (r *DatabaseRegistry) Update(t tenant.Model, characterId uint32, changes map[string]bool) (Model, error) {
+	err := database.ExecuteTransaction(r.db, func(tx *gorm.DB) error {
+		for inviteType, declined := range changes {
+			var err error
+			if declined {
+				err = decline(tx, t, characterId, inviteType)
+			} else {
+				err = allow(tx, t, characterId, inviteType)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Model{}, err
+	}
+	return r.Get(t, characterId)
+}
@@ -83,6 +83,34 @@ func RegisterInputHandler[M any](l logrus.FieldLogger) func(si jsonapi.ServerInf
 	}
 }
 
+// RegisterStreamHandler registers a handler holding its connection open, such as a Server-Sent Events stream. The
+// tenant headers are validated as for RegisterHandler, but no span is started, as it would remain open for as long as
+// the client stays connected.
+func RegisterStreamHandler(l logrus.FieldLogger) func(si jsonapi.ServerInformation) func(handlerName string, handler GetHandler) http.HandlerFunc {
+	return func(si jsonapi.ServerInformation) func(handlerName string, handler GetHandler) http.HandlerFunc {
+		return func(handlerName string, handler GetHandler) http.HandlerFunc {
+			fl := l.WithFields(logrus.Fields{"originator": handlerName, "type": "rest_handler"})
+			return server.ParseTenant(fl, context.Background(), func(tl logrus.FieldLogger, tctx context.Context) http.HandlerFunc {
+				return handler(&HandlerDependency{l: tl, ctx: tctx}, &HandlerContext{si: si})
+			})
+		}
+	}
+}
+
+type InviteIdHandler func(inviteId uint32) http.HandlerFunc
+
+func ParseInviteId(l logrus.FieldLogger, next InviteIdHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inviteId, err := strconv.Atoi(mux.Vars(r)["inviteId"])
+		if err != nil {
+			l.WithError(err).Errorf("Unable to properly parse inviteId from path.")
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		next(uint32(inviteId))(w, r)
+	}
+}
+
 type CharacterIdHandler func(characterId uint32) http.HandlerFunc
 
 func ParseCharacterId(l logrus.FieldLogger, next CharacterIdHandler) http.HandlerFunc {
@@ -96,3 +124,31 @@ func ParseCharacterId(l logrus.FieldLogger, next CharacterIdHandler) http.Handle
 		next(uint32(characterId))(w, r)
 	}
 }
+
+type BlockedIdHandler func(blockedId uint32) http.HandlerFunc
+
+func ParseBlockedId(l logrus.FieldLogger, next BlockedIdHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		blockedId, err := strconv.Atoi(mux.Vars(r)["blockedId"])
+		if err != nil {
+			l.WithError(err).Errorf("Unable to properly parse blockedId from path.")
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		next(uint32(blockedId))(w, r)
+	}
+}
+
+type TargetIdHandler func(targetId uint32) http.HandlerFunc
+
+func ParseTargetId(l logrus.FieldLogger, next TargetIdHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		targetId, err := strconv.Atoi(mux.Vars(r)["targetId"])
+		if err != nil {
+			l.WithError(err).Errorf("Unable to properly parse targetId from path.")
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		next(uint32(targetId))(w, r)
+	}
+}
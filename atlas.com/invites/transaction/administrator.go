@@ -0,0 +1,21 @@
+package transaction
+
+import (
+	"github.com/segmentio/kafka-go"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+func put(db *gorm.DB, key string, messages map[string][]kafka.Message, createdAt time.Time) error {
+	e := Entity{
+		Key:       key,
+		Messages:  messages,
+		CreatedAt: createdAt,
+	}
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&e).Error
+}
+
+func deleteCreatedBefore(db *gorm.DB, before time.Time) error {
+	return db.Where("created_at < ?", before).Delete(&Entity{}).Error
+}
@@ -0,0 +1,21 @@
+package transaction
+
+import (
+	"github.com/segmentio/kafka-go"
+	"gorm.io/gorm"
+	"time"
+)
+
+func Migration(db *gorm.DB) error {
+	return db.AutoMigrate(&Entity{})
+}
+
+type Entity struct {
+	Key       string                     `gorm:"primaryKey;not null"`
+	Messages  map[string][]kafka.Message `gorm:"serializer:json"`
+	CreatedAt time.Time                  `gorm:"not null;index"`
+}
+
+func (e Entity) TableName() string {
+	return "processed_transactions"
+}
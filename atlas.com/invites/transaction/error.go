@@ -0,0 +1,8 @@
+package transaction
+
+import "errors"
+
+var (
+	ErrInvalidCapacity = errors.New("transaction cache size must be positive")
+	ErrInvalidTtl      = errors.New("transaction cache ttl must be positive")
+)
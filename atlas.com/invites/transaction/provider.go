@@ -0,0 +1,21 @@
+package transaction
+
+import (
+	"atlas-invites/database"
+	"github.com/Chronicle20/atlas-model/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+func getByKey(key string) func(since time.Time) database.EntityProvider[Entity] {
+	return func(since time.Time) database.EntityProvider[Entity] {
+		return func(db *gorm.DB) model.Provider[Entity] {
+			var result Entity
+			err := db.Where("key = ? AND created_at >= ?", key, since).First(&result).Error
+			if err != nil {
+				return model.ErrorProvider[Entity](err)
+			}
+			return model.FixedProvider(result)
+		}
+	}
+}
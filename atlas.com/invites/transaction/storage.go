@@ -0,0 +1,38 @@
+package transaction
+
+import (
+	"errors"
+	"github.com/segmentio/kafka-go"
+	"gorm.io/gorm"
+	"time"
+)
+
+// DatabaseStore is a Store backed by the processed_transactions table, shared by every instance. When constructed with
+// an open transaction, entries are committed or rolled back together with the other work performed in that transaction.
+type DatabaseStore struct {
+	db  *gorm.DB
+	ttl time.Duration
+}
+
+func NewDatabaseStore(db *gorm.DB, ttl time.Duration) *DatabaseStore {
+	return &DatabaseStore{db: db, ttl: ttl}
+}
+
+func (s *DatabaseStore) Get(key string) (map[string][]kafka.Message, bool, error) {
+	e, err := getByKey(key)(time.Now().Add(-s.ttl))(s.db)()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return e.Messages, true, nil
+}
+
+func (s *DatabaseStore) Put(key string, messages map[string][]kafka.Message) error {
+	return put(s.db, key, messages, time.Now())
+}
+
+func (s *DatabaseStore) Prune() error {
+	return deleteCreatedBefore(s.db, time.Now().Add(-s.ttl))
+}
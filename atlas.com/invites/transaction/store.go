@@ -0,0 +1,163 @@
+package transaction
+
+import (
+	"container/list"
+	"github.com/segmentio/kafka-go"
+	"gorm.io/gorm"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultCapacity = 10000
+	DefaultTtl      = 10 * time.Minute
+)
+
+// Store remembers the messages emitted while processing a transaction so a redelivered command can be answered with
+// the original outcome. Entries are forgotten once they exceed a ttl. Prune removes such entries. Implementations must be
+// safe for concurrent use.
+type Store interface {
+	Get(key string) (map[string][]kafka.Message, bool, error)
+	Put(key string, messages map[string][]kafka.Message) error
+	Prune() error
+}
+
+type entry struct {
+	key      string
+	messages map[string][]kafka.Message
+	created  time.Time
+}
+
+// InMemoryStore is a Store local to the instance. Entries are evicted once they exceed the ttl or, oldest first, once
+// capacity is reached.
+type InMemoryStore struct {
+	lock     sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+func NewInMemoryStore(capacity int, ttl time.Duration) *InMemoryStore {
+	return &InMemoryStore{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// StoreFromEnv builds a Store whose entries are kept for TRANSACTION_CACHE_TTL. Entries are kept in the database when
+// one is configured, so every instance answers a redelivered command alike, and are otherwise kept in memory, up to
+// TRANSACTION_CACHE_SIZE of them. Unset values keep DefaultCapacity and DefaultTtl, while malformed or non-positive
+// values are reported as errors.
+func StoreFromEnv(db *gorm.DB) (Store, error) {
+	capacity := DefaultCapacity
+	if val, ok := os.LookupEnv("TRANSACTION_CACHE_SIZE"); ok && val != "" {
+		var err error
+		capacity, err = strconv.Atoi(val)
+		if err != nil {
+			return nil, err
+		}
+		if capacity <= 0 {
+			return nil, ErrInvalidCapacity
+		}
+	}
+	ttl := DefaultTtl
+	if val, ok := os.LookupEnv("TRANSACTION_CACHE_TTL"); ok && val != "" {
+		var err error
+		ttl, err = time.ParseDuration(val)
+		if err != nil {
+			return nil, err
+		}
+		if ttl <= 0 {
+			return nil, ErrInvalidTtl
+		}
+	}
+	if db != nil {
+		return NewDatabaseStore(db, ttl), nil
+	}
+	return NewInMemoryStore(capacity, ttl), nil
+}
+
+var store Store
+var once sync.Once
+
+// InitStore sets the Store used by the service, and panics if one is already set.
+func InitStore(s Store) {
+	set := false
+	once.Do(func() {
+		store = s
+		set = true
+	})
+	if !set {
+		panic("transaction store already set")
+	}
+}
+
+// GetStore returns the service wide Store, defaulting to an in-memory one of DefaultCapacity entries kept for
+// DefaultTtl.
+func GetStore() Store {
+	once.Do(func() {
+		store = NewInMemoryStore(DefaultCapacity, DefaultTtl)
+	})
+	return store
+}
+
+// Bind returns a Store which reads and writes through db when s is a DatabaseStore, so its entries are committed or
+// rolled back together with the transaction db belongs to. Any other Store is returned as is.
+func Bind(s Store, db *gorm.DB) Store {
+	if ds, ok := s.(*DatabaseStore); ok {
+		return NewDatabaseStore(db, ds.ttl)
+	}
+	return s
+}
+
+func (s *InMemoryStore) Get(key string) (map[string][]kafka.Message, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.prune(time.Now())
+	if el, ok := s.entries[key]; ok {
+		return el.Value.(*entry).messages, true, nil
+	}
+	return nil, false, nil
+}
+
+func (s *InMemoryStore) Put(key string, messages map[string][]kafka.Message) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	s.prune(now)
+	if el, ok := s.entries[key]; ok {
+		s.order.Remove(el)
+	}
+	s.entries[key] = s.order.PushBack(&entry{key: key, messages: messages, created: now})
+	for s.order.Len() > s.capacity {
+		s.evict(s.order.Front())
+	}
+	return nil
+}
+
+func (s *InMemoryStore) Prune() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.prune(time.Now())
+	return nil
+}
+
+// prune evicts expired entries. Entries are ordered by creation, so pruning stops at the first live entry.
+func (s *InMemoryStore) prune(now time.Time) {
+	for el := s.order.Front(); el != nil; el = s.order.Front() {
+		if now.Sub(el.Value.(*entry).created) < s.ttl {
+			return
+		}
+		s.evict(el)
+	}
+}
+
+func (s *InMemoryStore) evict(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.entries, el.Value.(*entry).key)
+}